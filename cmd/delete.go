@@ -0,0 +1,102 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+// deleteTests function removes tests along with all of their data
+func deleteTests(cmd *cobra.Command, args []string) {
+	inputPath, tests := args[0], args[1:]
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer tx.Rollback()
+
+	// all tests are looked up first, so nothing is deleted if any is missing
+	ids := make([]int64, len(tests))
+	descriptions := make([]string, len(tests))
+	for i, test := range tests {
+		if ids[i], descriptions[i], err = findTest(tx, test); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// statistics are removed by foreign key cascade
+	for i, id := range ids {
+		if _, err := tx.Exec(`DELETE FROM tests WHERE test_id = ?;`, id); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted test %d: %s\n", id, descriptions[i])
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateDeleteArgs function validates arguments for "delete" command
+func validateDeleteArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) < 2 {
+		return errors.New("Please provide path to DB file and at least one test id or description")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	return nil
+}
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   `delete path/to/db/file "test id or description" [other tests...]`,
+	Short: "Delete tests from a database",
+	Long: `Delete tests identified by id or description along with all
+of their statistics. Use "invalidate" command to keep test data stored
+but exclude it from reports.`,
+	Args: validateDeleteArgs,
+	Run:  deleteTests,
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+}
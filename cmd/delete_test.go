@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+// openTestDB function creates an initialized DB in a temporary dir
+func openTestDB(t *testing.T) (*sql.DB, string, func()) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test.db")
+	db, err := dbutils.Open(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	if err := dbutils.Initialize(db); err != nil {
+		db.Close()
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return db, path, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// insertChildRows function stores a row of every table with data of a test
+func insertChildRows(t *testing.T, db *sql.DB, testID int64) {
+	if err := insertRequestStats(db, testID, []RequestStats{{Label: "home", Samples: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := insertResponseCodes(db, testID, map[string]map[string]int{"home": {"200": 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := insertRawSamples(db, testID, []rawSample{{Label: "home", Elapsed: 100}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := insertHARPages(db, testID, []HARPage{{ID: "page_1"}}); err != nil {
+		t.Fatal(err)
+	}
+	statements := []string{
+		`INSERT INTO wpt_tests (test_id, url, location, connectivity, browser) VALUES (?, 'https://example.com', 'dulles', 'Cable', 'Chrome');`,
+		`INSERT INTO wpt_metrics (test_id, view, statistic, name, value) VALUES (?, 'first', 'avg', 'TTFB', 100);`,
+		`INSERT INTO wpt_breakdowns (test_id, view, dimension, name, requests, bytes, load_time) VALUES (?, 'first', 'domain', 'example.com', 1, 1, 1);`,
+		`INSERT INTO lighthouse_statistics (test_id, performance) VALUES (?, 0.9);`,
+		`INSERT INTO har_timings (test_id, url, requests, blocked, dns, connect, ssl, send, wait, receive) VALUES (?, '/', 1, 0, 0, 0, 0, 0, 1, 1);`,
+		`INSERT INTO test_tags (test_id, key, value) VALUES (?, 'env', 'qa');`,
+		`INSERT INTO request_percentiles (test_id, label, percentile, value) VALUES (?, 'home', 75, 100);`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement, testID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// countChildRows function returns amount of rows of a test per table
+func countChildRows(t *testing.T, db *sql.DB, testID int64) map[string]int {
+	counts := map[string]int{}
+	for _, table := range mergeTables {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM `+table.name+` WHERE test_id = ?;`, testID).Scan(&count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[table.name] = count
+	}
+
+	return counts
+}
+
+func TestDeletingTests(t *testing.T) {
+	db, path, cleanup := openTestDB(t)
+	defer cleanup()
+
+	var ids []int64
+	for _, description := range []string{"obsolete", "kept"} {
+		testID, err := insertTest(db, description, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		insertChildRows(t, db, testID)
+		ids = append(ids, testID)
+	}
+	for table, count := range countChildRows(t, db, ids[0]) {
+		if count != 1 {
+			t.Fatalf("Expected 1 row of deleted test in %s before delete, got %d", table, count)
+		}
+	}
+
+	deleteTests(deleteCmd, []string{path, "obsolete"})
+
+	for table, count := range countChildRows(t, db, ids[0]) {
+		if count != 0 {
+			t.Errorf("Expected rows of deleted test to be removed from %s, got %d", table, count)
+		}
+	}
+	for table, count := range countChildRows(t, db, ids[1]) {
+		if count != 1 {
+			t.Errorf("Expected rows of other test to be kept in %s, got %d", table, count)
+		}
+	}
+}
+
+func TestRenamingTest(t *testing.T) {
+	db, path, cleanup := openTestDB(t)
+	defer cleanup()
+
+	for _, description := range []string{"nightly", "weekly"} {
+		if _, err := insertTest(db, description, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renameTest(renameCmd, []string{path, "nightly", "nightly 1.2"})
+
+	var description string
+	if err := db.QueryRow(`SELECT description FROM tests WHERE test_id = 1;`).Scan(&description); err != nil {
+		t.Fatal(err)
+	}
+	if description != "nightly 1.2" {
+		t.Errorf("Expected test to be renamed, got %q", description)
+	}
+
+	// rename command reports a used description relying on this check
+	_, err := db.Exec(`UPDATE tests SET description = 'weekly' WHERE test_id = 1;`)
+	if !dbutils.IsUniqueViolation(err) {
+		t.Errorf("Expected unique violation for used description, got %v", err)
+	}
+}
+
+func TestInvalidatingTests(t *testing.T) {
+	db, path, cleanup := openTestDB(t)
+	defer cleanup()
+
+	for _, description := range []string{"nightly", "weekly"} {
+		if _, err := insertTest(db, description, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() { invalidReason, invalidRevert = "", false }()
+
+	status := func() (bool, sql.NullString) {
+		var (
+			invalid bool
+			reason  sql.NullString
+		)
+		err := db.QueryRow(`SELECT invalid, invalid_reason FROM tests WHERE test_id = 2;`).Scan(&invalid, &reason)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return invalid, reason
+	}
+
+	invalidReason = "load generator was overloaded"
+	invalidateTests(invalidateCmd, []string{path, "weekly"})
+	if invalid, reason := status(); !invalid || reason.String != invalidReason {
+		t.Errorf("Expected test to be invalid with reason, got %v and %q", invalid, reason.String)
+	}
+
+	invalidReason, invalidRevert = "", true
+	invalidateTests(invalidateCmd, []string{path, "2"})
+	if invalid, reason := status(); invalid || reason.Valid {
+		t.Errorf("Expected test to be valid without reason, got %v and %q", invalid, reason.String)
+	}
+
+	var invalid bool
+	db.QueryRow(`SELECT invalid FROM tests WHERE test_id = 1;`).Scan(&invalid)
+	if invalid {
+		t.Error("Expected other test to stay valid")
+	}
+}
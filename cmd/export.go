@@ -15,60 +15,552 @@
 package cmd
 
 import (
-	"database/sql"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/dakaraj/ptrend/dbutils"
 	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
 	"github.com/spf13/cobra"
 )
 
-// metrics variable contains valid values for "metric" flag
-var metrics = []string{"average", "median", "perc90", "perc95", "min", "max"}
+var (
+	// metrics variable contains valid values for "metric" flag
+	metrics = []string{"average", "median", "perc90", "perc95", "perc99", "min", "max"}
+	// exportMetricList contains valid values for "metric" flag of export,
+	// "codes" exports amounts of responses per code instead of statistics.
+	// Percentiles stored by "recompute" command are selected by "percNN"
+	exportMetricList = append(append([]string{}, metrics...), "codes")
+	// exportSourceList contains valid values for "source" flag of export
+	exportSourceList = []string{"jmeter", "wpt", "har"}
+	// wptViewList contains valid values for "view" flag
+	wptViewList = []string{"first", "repeat", "both"}
+	// wptStatisticList contains valid values for "statistic" flag
+	wptStatisticList = []string{"avg", "std", "med", "runs"}
+	// wptBreakdownList contains valid values for "breakdown" flag
+	wptBreakdownList = []string{"none", "domain", "content_type"}
+	// harDataList contains valid values for "har-data" flag
+	harDataList    = []string{"requests", "pages", "phases"}
+	wptView        string
+	wptStatistic   string
+	wptBreakdownBy string
+	harData        string
+	exportDetails  bool
+)
+
+// harExportTables contains queries selecting a name and its values per HAR
+// test along with names of those values, by values of "har-data" flag
+var harExportTables = map[string]struct {
+	query  string
+	fields []string
+}{
+	"pages": {`
+SELECT t.description, h.page, h.on_content_load, h.on_load
+FROM har_pages AS h
+	JOIN tests AS t ON h.test_id = t.test_id
+WHERE t.type_id = 4
+ORDER BY h.page ASC, t.test_id ASC;
+`, []string{"onContentLoad", "onLoad"}},
+	"phases": {`
+SELECT t.description, h.url, h.requests, h.blocked, h.dns, h.connect,
+	h.ssl, h.send, h.wait, h.receive
+FROM har_timings AS h
+	JOIN tests AS t ON h.test_id = t.test_id
+WHERE t.type_id = 4
+ORDER BY h.url ASC, t.test_id ASC;
+`, []string{"requests", "blocked", "dns", "connect", "ssl", "send", "wait", "receive"}},
+}
+
+// extraPercentile function returns a percentile selected by "percNN"
+// metric unless it is one of percentiles stored along with statistics
+func extraPercentile(metric string) (int, bool) {
+	if !strings.HasPrefix(metric, "perc") || isOneOf(metric, metrics) {
+		return 0, false
+	}
+	perc, err := strconv.Atoi(strings.TrimPrefix(metric, "perc"))
+	if err != nil || perc <= 0 || perc >= 100 {
+		return 0, false
+	}
+
+	return perc, true
+}
+
+// isOneOf function checks if value is present in a list of valid values
+func isOneOf(value string, list []string) bool {
+	for _, val := range list {
+		if val == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wptRowKey identifies a group of WPT metric values exported together
+type wptRowKey struct {
+	view string
+	run  int
+	step string
+}
+
+// exportWPTData function writes WPT metrics of selected view and statistic
+// kind into CSV file. Each row represents a metric found in data and each
+// column a test. Individual runs are exported as separate rows
+func exportWPTData(tests []string, fileHandler *csv.Writer) {
+	statistic := wptStatistic
+	if statistic == "runs" {
+		statistic = "run"
+	}
+	rows, err := DB.Query(`
+SELECT t.description, w.view, w.run, w.step, w.name, w.value
+FROM wpt_metrics AS w
+	JOIN tests AS t ON w.test_id = t.test_id
+WHERE t.type_id = 2 AND w.statistic = ?
+ORDER BY t.test_id ASC, w.wpt_metric_id ASC;
+`, statistic)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	testIdx := make(map[string]int, len(tests))
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	// values are grouped by view, run and step, missing ones are left empty
+	values := map[wptRowKey]map[string][]string{}
+	// steps are ordered as they appear in tests
+	stepOrder := map[string]int{}
+	for rows.Next() {
+		var (
+			description string
+			key         wptRowKey
+			name        string
+			value       string
+		)
+		rows.Scan(&description, &key.view, &key.run, &key.step, &name, &value)
+		idx, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		if _, ok := stepOrder[key.step]; !ok {
+			stepOrder[key.step] = len(stepOrder)
+		}
+		if wptView != "both" && wptView != key.view {
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			values[key] = map[string][]string{}
+		}
+		if _, ok := values[key][name]; !ok {
+			values[key][name] = make([]string, len(tests))
+		}
+		values[key][name][idx] = value
+	}
+
+	// ordering groups by view first, run number and step next
+	viewOrder := map[string]int{}
+	for i, view := range wptViews {
+		viewOrder[view.name] = i
+	}
+	keys := make([]wptRowKey, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].view != keys[j].view {
+			return viewOrder[keys[i].view] < viewOrder[keys[j].view]
+		}
+		if keys[i].run != keys[j].run {
+			return keys[i].run < keys[j].run
+		}
+		return stepOrder[keys[i].step] < stepOrder[keys[j].step]
+	})
+
+	fileHandler.Write(append([]string{"Metric\\Test"}, tests...))
+	writeTestDetails(tests, fileHandler)
+	for _, key := range keys {
+		var suffix []string
+		if key.step != "" {
+			suffix = append(suffix, key.step)
+		}
+		if wptView == "both" {
+			suffix = append(suffix, key.view)
+		}
+		if wptStatistic == "runs" {
+			suffix = append(suffix, fmt.Sprintf("run %d", key.run))
+		}
+		names := make([]string, 0, len(values[key]))
+		for name := range values[key] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			label := name
+			if len(suffix) > 0 {
+				label = fmt.Sprintf("%s (%s)", name, strings.Join(suffix, ", "))
+			}
+			fileHandler.Write(append([]string{label}, values[key][name]...))
+		}
+	}
+}
+
+// exportWPTBreakdowns function writes WPT requests aggregated by domain
+// or content type into CSV file. Each name gets a row per request count,
+// bytes and load time, so regressing domains and resource types stand out
+func exportWPTBreakdowns(tests []string, fileHandler *csv.Writer) {
+	rows, err := DB.Query(`
+SELECT t.description, b.view, b.name, b.requests, b.bytes, b.load_time
+FROM wpt_breakdowns AS b
+	JOIN tests AS t ON b.test_id = t.test_id
+WHERE t.type_id = 2 AND b.dimension = ?
+ORDER BY b.name ASC, t.test_id ASC;
+`, wptBreakdownBy)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	testIdx := make(map[string]int, len(tests))
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	fields := []string{"requests", "bytes", "load time"}
+	// values are grouped by view and name, missing ones are left empty
+	type breakdownKey struct{ view, name string }
+	values := map[breakdownKey][][]string{}
+	var keys []breakdownKey
+	for rows.Next() {
+		var (
+			description string
+			key         breakdownKey
+			totals      = make([]string, len(fields))
+		)
+		rows.Scan(&description, &key.view, &key.name, &totals[0], &totals[1], &totals[2])
+		idx, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		if wptView != "both" && wptView != key.view {
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			values[key] = make([][]string, len(fields))
+			for i := range fields {
+				values[key][i] = make([]string, len(tests))
+			}
+			keys = append(keys, key)
+		}
+		for i, total := range totals {
+			values[key][i][idx] = total
+		}
+	}
+
+	fileHandler.Write(append([]string{"Name\\Test"}, tests...))
+	writeTestDetails(tests, fileHandler)
+	for _, key := range keys {
+		for i, field := range fields {
+			suffix := field
+			if wptView == "both" {
+				suffix = fmt.Sprintf("%s, %s", field, key.view)
+			}
+			label := fmt.Sprintf("%s (%s)", key.name, suffix)
+			fileHandler.Write(append([]string{label}, values[key][i]...))
+		}
+	}
+}
+
+// exportHARTimings function writes HAR page timings or average timing
+// phases of requests per URL into CSV file. Each page or URL gets a row
+// per value, so slow pages and regressing phases stand out
+func exportHARTimings(tests []string, fileHandler *csv.Writer) {
+	table := harExportTables[harData]
+	rows, err := DB.Query(table.query)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	testIdx := make(map[string]int, len(tests))
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	// values are grouped by name, missing ones are left empty
+	values := map[string][][]string{}
+	var names []string
+	for rows.Next() {
+		var (
+			description string
+			name        string
+			timings     = make([]string, len(table.fields))
+		)
+		scanArgs := []interface{}{&description, &name}
+		for i := range timings {
+			scanArgs = append(scanArgs, &timings[i])
+		}
+		rows.Scan(scanArgs...)
+		idx, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		if _, ok := values[name]; !ok {
+			values[name] = make([][]string, len(table.fields))
+			for i := range table.fields {
+				values[name][i] = make([]string, len(tests))
+			}
+			names = append(names, name)
+		}
+		for i, timing := range timings {
+			values[name][i][idx] = timing
+		}
+	}
+
+	fileHandler.Write(append([]string{"Name\\Test"}, tests...))
+	writeTestDetails(tests, fileHandler)
+	for _, name := range names {
+		for i, field := range table.fields {
+			label := fmt.Sprintf("%s (%s)", name, field)
+			fileHandler.Write(append([]string{label}, values[name][i]...))
+		}
+	}
+}
+
+// exportResponseCodes function writes amounts of responses per code
+// (or error name) into CSV file. Each label gets a row per code
+func exportResponseCodes(tests []string, testTypeID int, fileHandler *csv.Writer) {
+	rows, err := DB.Query(`
+SELECT t.description, c.label, c.code, c.count
+FROM response_codes AS c
+	JOIN tests AS t ON c.test_id = t.test_id
+WHERE t.type_id = ?
+ORDER BY c.label ASC, c.code ASC, t.test_id ASC;
+`, testTypeID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	testIdx := make(map[string]int, len(tests))
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	// counts are grouped by label and code, missing ones are left empty
+	type codeKey struct{ label, code string }
+	values := map[codeKey][]string{}
+	var keys []codeKey
+	for rows.Next() {
+		var (
+			description string
+			key         codeKey
+			count       string
+		)
+		rows.Scan(&description, &key.label, &key.code, &count)
+		idx, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			values[key] = make([]string, len(tests))
+			keys = append(keys, key)
+		}
+		values[key][idx] = count
+	}
+
+	fileHandler.Write(append([]string{"Request\\Test"}, tests...))
+	writeTestDetails(tests, fileHandler)
+	for _, key := range keys {
+		label := fmt.Sprintf("%s (%s)", key.label, key.code)
+		fileHandler.Write(append([]string{label}, values[key]...))
+	}
+}
+
+// exportRequestPercentiles function writes a percentile stored by
+// "recompute" command into CSV file. Each label gets a row and values
+// of tests the percentile is not stored for are left empty
+func exportRequestPercentiles(tests []string, testTypeID, perc int, fileHandler *csv.Writer) {
+	rows, err := DB.Query(`
+SELECT t.description, p.label, p.value
+FROM request_percentiles AS p
+	JOIN tests AS t ON p.test_id = t.test_id
+WHERE t.type_id = ? AND p.percentile = ?
+ORDER BY p.label ASC, t.test_id ASC;
+`, testTypeID, perc)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	testIdx := make(map[string]int, len(tests))
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	values := map[string][]string{}
+	var labels []string
+	for rows.Next() {
+		var (
+			description, label string
+			value              float64
+		)
+		rows.Scan(&description, &label, &value)
+		idx, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		if _, ok := values[label]; !ok {
+			values[label] = make([]string, len(tests))
+			labels = append(labels, label)
+		}
+		values[label][idx] = formatStat(value)
+	}
+
+	fileHandler.Write(append([]string{"Request\\Test"}, tests...))
+	writeTestDetails(tests, fileHandler)
+	for _, label := range labels {
+		fileHandler.Write(append([]string{label}, values[label]...))
+	}
+}
+
+// testDetailRow struct contains a name of a test attribute exported
+// as a row along with a function returning its value
+type testDetailRow struct {
+	name  string
+	value func(d TestDetails) string
+}
+
+// testDetailRows contains attributes exported for tests of any type
+var testDetailRows = []testDetailRow{
+	{"Started", func(d TestDetails) string { return d.Started }},
+	{"Finished", func(d TestDetails) string { return d.Finished }},
+	{"Duration", func(d TestDetails) string { return d.Duration }},
+	{"Environment", func(d TestDetails) string { return d.Environment }},
+	{"Build", func(d TestDetails) string { return d.Build }},
+	{"Revision", func(d TestDetails) string { return d.Revision }},
+	{"Tags", func(d TestDetails) string { return d.Tags }},
+	{"Invalid", func(d TestDetails) string { return d.Invalid }},
+}
+
+// wptDetailRows contains attributes exported for WPT tests only
+var wptDetailRows = []testDetailRow{
+	{"URL", func(d TestDetails) string { return d.URL }},
+	{"Location", func(d TestDetails) string { return d.Location }},
+	{"Connectivity", func(d TestDetails) string { return d.Connectivity }},
+	{"Browser", func(d TestDetails) string { return d.Browser }},
+	{"Completed", func(d TestDetails) string { return d.Completed }},
+}
+
+// writeTestDetails function writes attributes of tests as rows
+// following the header if "details" flag is set
+func writeTestDetails(tests []string, fileHandler *csv.Writer) {
+	if !exportDetails {
+		return
+	}
+	attrs := testDetailRows
+	if testType == "wpt" {
+		attrs = append(append([]testDetailRow{}, testDetailRows...), wptDetailRows...)
+	}
+	details := getTestDetailsFromDB(DB, tests)
+	for _, attr := range attrs {
+		row := []string{attr.name}
+		for _, d := range details {
+			row = append(row, attr.value(d))
+		}
+		fileHandler.Write(row)
+	}
+}
 
 // exportData function takes data from database and exports it to CSV file
 func exportData(cmd *cobra.Command, args []string) {
 	inputPath := args[0]
 	var err error
-	DB, err = sql.Open("sqlite3", inputPath)
+	DB, err = dbutils.Open(inputPath)
 	defer DB.Close()
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 
-	tests := getTestsFromDB(DB)
-	testsNumber := len(tests)
+	// upgrading schema of DB created by earlier builds
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// create/overwrite an existing file
+	file, err := os.Create(exportFileName)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fileHandler := csv.NewWriter(file)
+	defer fileHandler.Flush() // write buffered data to a file
+
+	if testType == "wpt" && wptBreakdownBy != "none" {
+		exportWPTBreakdowns(getTestsFromDB(DB, 2), fileHandler)
+		return
+	}
+	if testType == "wpt" {
+		exportWPTData(getTestsFromDB(DB, 2), fileHandler)
+		return
+	}
+	if testType == "har" && harData != "requests" {
+		exportHARTimings(getTestsFromDB(DB, 4), fileHandler)
+		return
+	}
+
+	// total times of HAR requests are kept as request statistics
+	testTypeID := 1
+	if testType == "har" {
+		testTypeID = 4
+	}
+	tests := getTestsFromDB(DB, testTypeID)
+	if metric == "codes" {
+		exportResponseCodes(tests, testTypeID, fileHandler)
+		return
+	}
+	if perc, ok := extraPercentile(metric); ok {
+		exportRequestPercentiles(tests, testTypeID, perc, fileHandler)
+		return
+	}
+	testIdx := make(map[string]int, len(tests))
+	for i, v := range tests {
+		testIdx[v] = i
+	}
 
 	// depending on "metric" flag value returns a corresponding statistics data
+	dialect := dbutils.DialectOf(DB)
 	rows, err := DB.Query(fmt.Sprintf(`
 SELECT request_statistics.label,
-	GROUP_CONCAT(tests.description),
-	GROUP_CONCAT(request_statistics.%s)
+	%s,
+	%s
 FROM request_statistics
 	JOIN tests ON request_statistics.test_id = tests.test_id
-WHERE tests.type_id = 1
+WHERE tests.type_id = ?
 GROUP BY request_statistics.label;
-`, metric))
+`, dialect.GroupConcat("tests.description", ","),
+		dialect.GroupConcat("request_statistics."+metric, ",")), testTypeID)
 	defer rows.Close()
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 
-	// create/overwrite an existing file
-	file, err := os.Create(exportFileName)
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
-	}
-
-	fileHandler := csv.NewWriter(file)
 	// buffer header to a fileWriter
 	fileHandler.Write(append([]string{"Request\\Test"}, tests...))
+	writeTestDetails(tests, fileHandler)
 	for rows.Next() {
 		var (
 			label        string
@@ -78,26 +570,15 @@ GROUP BY request_statistics.label;
 		rows.Scan(&label, &descriptions, &stats)
 		// splitting all concatenated data into arrays
 		splitDesc := strings.Split(descriptions, ",")
-		splitStats := strings.Split(stats, ",")
-		// If there is no info for particular transaction in some test
-		// then fill all values with zeroes
-		if len(splitDesc) != testsNumber {
-			diff := testsNumber - len(splitDesc)
-			splitDesc = append(splitDesc, make([]string, diff)...)
-			for i, v := range tests {
-				if v != splitDesc[i] {
-					// inserting missing values
-					copy(splitDesc[i+1:], splitDesc[i:])
-					splitDesc[i] = v
-					// insert zero value at current index to each array
-					splitStats = fillMissingStatValue(i, splitStats, "")
-				}
-			}
+		if !hasAnyTest(splitDesc, testIdx) {
+			continue
 		}
+		// If there is no info for particular transaction in some test
+		// then leave its value empty
+		splitStats := orderStatValues(splitDesc, strings.Split(stats, ","), testIdx, "")
 		// buffer stats line into fileWriter
 		fileHandler.Write(append([]string{label}, splitStats...))
 	}
-	fileHandler.Flush() // write biffered data to a file
 }
 
 // validateExportArgs function validates arguments for "export" command
@@ -107,8 +588,8 @@ func validateExportArgs(cmd *cobra.Command, args []string) error {
 		return errors.New("Please provide path to input DB file as a single argument")
 	}
 
-	// validate if input file exists and is not a dir
-	if fileInf, err := os.Stat(args[0]); err != nil || fileInf.IsDir() {
+	// validate if input DB exists
+	if !dbExists(args[0]) {
 		return errors.New("Input file path is invalid or file does not exist")
 	}
 
@@ -122,16 +603,37 @@ func validateExportArgs(cmd *cobra.Command, args []string) error {
 		return errors.New("Output file path is invalid")
 	}
 
+	// validate filter and order flags
+	if err := validateTestFilter(); err != nil {
+		return err
+	}
+
+	// validate source flag is valid
+	if !isOneOf(testType, exportSourceList) {
+		return fmt.Errorf("Test type is not one of the following: %v", exportSourceList)
+	}
+
 	// validate if metric flag has a valid value
-	valid := false
-	for _, val := range metrics {
-		if val == metric {
-			valid = true
-			break
+	if _, ok := extraPercentile(metric); testType != "wpt" && !ok && !isOneOf(metric, exportMetricList) {
+		return fmt.Errorf("Metric is not one of the following: %v or percNN", exportMetricList)
+	}
+
+	// validate WPT specific flags
+	if testType == "wpt" {
+		if !isOneOf(wptView, wptViewList) {
+			return fmt.Errorf("View is not one of the following: %v", wptViewList)
+		}
+		if !isOneOf(wptStatistic, wptStatisticList) {
+			return fmt.Errorf("Statistic is not one of the following: %v", wptStatisticList)
+		}
+		if !isOneOf(wptBreakdownBy, wptBreakdownList) {
+			return fmt.Errorf("Breakdown is not one of the following: %v", wptBreakdownList)
 		}
 	}
-	if !valid {
-		return fmt.Errorf("Metric is not one of the following: %v", metrics)
+
+	// validate HAR specific flags
+	if testType == "har" && !isOneOf(harData, harDataList) {
+		return fmt.Errorf("HAR data is not one of the following: %v", harDataList)
 	}
 
 	return nil
@@ -142,7 +644,18 @@ var exportCmd = &cobra.Command{
 	Use:   "export path/to/db/file",
 	Short: "Export all trends data to a CSV format",
 	Long: `Export all trends data gathered previously to a CSV format.
-Can be customized with filename and delimiter.`,
+Can be customized with filename and delimiter. Metric "codes" exports
+amounts of responses per code or error of each request. Metric "percNN",
+e.g. "perc75", exports an extra percentile stored by "recompute" command.
+For WPT source each row
+represents a metric of selected view (first/repeat/both) and statistic.
+Statistic "runs" exports metrics of every individual WPT run.
+Breakdown exports requests, bytes and load time per domain or
+content type averaged over WPT runs instead of metrics.
+For HAR source total request times are exported by default, "har-data"
+flag selects page timings (onContentLoad and onLoad) or average timing
+phases of requests per URL instead.
+Tests can be filtered and ordered by their attributes.`,
 	Args: validateExportArgs,
 	Run:  exportData,
 }
@@ -152,5 +665,12 @@ func init() {
 
 	exportCmd.Flags().StringVarP(&delimiter, "delimiter", "d", ",", "Single character to be used as delimiter")
 	exportCmd.Flags().StringVarP(&exportFileName, "name", "n", "export.csv", "Export file name")
-	exportCmd.Flags().StringVarP(&metric, "metric", "m", "average", fmt.Sprintf("Select a metric for export: %v", metrics))
+	exportCmd.Flags().StringVarP(&metric, "metric", "m", "average", fmt.Sprintf("Select a metric for export: %v", exportMetricList))
+	exportCmd.Flags().StringVarP(&testType, "source", "s", "jmeter", fmt.Sprintf("Chose data source type for export: %v", exportSourceList))
+	exportCmd.Flags().StringVarP(&wptView, "view", "v", "first", fmt.Sprintf("Select WPT view for export: %v", wptViewList))
+	exportCmd.Flags().StringVar(&wptStatistic, "statistic", "med", fmt.Sprintf("Select WPT statistic for export: %v", wptStatisticList))
+	exportCmd.Flags().StringVarP(&wptBreakdownBy, "breakdown", "b", "none", fmt.Sprintf("Select WPT requests breakdown for export: %v", wptBreakdownList))
+	exportCmd.Flags().StringVar(&harData, "har-data", "requests", fmt.Sprintf("Select HAR data for export: %v", harDataList))
+	exportCmd.Flags().BoolVar(&exportDetails, "details", false, "Add rows with test start time, duration, environment, build, revision, tags, invalidation reason and WPT test attributes")
+	addTestFilterFlags(exportCmd)
 }
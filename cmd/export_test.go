@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestExportingHARTimings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	testID, err := insertTest(DB, "har", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := HARPage{Title: "home"}
+	page.PageTimings.OnContentLoad, page.PageTimings.OnLoad = 300, 500
+	if err := insertHARPages(DB, testID, []HARPage{page}); err != nil {
+		t.Fatal(err)
+	}
+	err = insertHARTimings(DB, testID, map[string][]int{"https://example.com/": {100, 200}},
+		map[string]*HARTimings{"https://example.com/": {DNS: 10, Wait: 150, Receive: 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	for _, data := range []string{"pages", "phases"} {
+		harData = data
+		writer := csv.NewWriter(&buffer)
+		exportHARTimings([]string{"har"}, writer)
+		writer.Flush()
+	}
+	harData = "requests"
+
+	expected := `Name\Test,har
+home (onContentLoad),300
+home (onLoad),500
+Name\Test,har
+https://example.com/ (requests),2
+https://example.com/ (blocked),0
+https://example.com/ (dns),5
+https://example.com/ (connect),0
+https://example.com/ (ssl),0
+https://example.com/ (send),0
+https://example.com/ (wait),75
+https://example.com/ (receive),10
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected export:\n%s", buffer.String())
+	}
+}
+
+func TestExportingResponseCodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	var tests []string
+	for i, codes := range []map[string]map[string]int{
+		{"/login": {"200": 98, "500": 2}},
+		{"/login": {"200": 100}, "/search": {"ETIMEDOUT": 3}},
+	} {
+		description := fmt.Sprintf("artillery %d", i+1)
+		testID, err := insertTest(DB, description, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := insertResponseCodes(DB, testID, codes); err != nil {
+			t.Fatal(err)
+		}
+		tests = append(tests, description)
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	exportResponseCodes(tests, 1, writer)
+	writer.Flush()
+	expected := `Request\Test,artillery 1,artillery 2
+/login (200),98,100
+/login (500),2,
+/search (ETIMEDOUT),,3
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected export:\n%s", buffer.String())
+	}
+}
+
+func TestExportingRecomputedPercentiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	var tests []string
+	for _, description := range []string{"jmeter 1", "jmeter 2"} {
+		testID, err := insertTest(DB, description, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests = append(tests, description)
+		if description == "jmeter 2" {
+			continue
+		}
+		samples := []rawSample{
+			{Label: "/login", Elapsed: 100, Success: true},
+			{Label: "/login", Elapsed: 300, Success: true},
+		}
+		settings := recomputeSettings{percentiles: []int{75}}
+		if _, err := recomputeTest(DB, testID, samples, settings); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if perc, ok := extraPercentile("perc75"); !ok || perc != 75 {
+		t.Fatalf("Expected perc75 to select 75th percentile, got %d", perc)
+	}
+	for _, metric := range []string{"perc90", "perc100", "percent", "average"} {
+		if _, ok := extraPercentile(metric); ok {
+			t.Errorf("Expected %q not to select an extra percentile", metric)
+		}
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	exportRequestPercentiles(tests, 1, 75, writer)
+	writer.Flush()
+	expected := `Request\Test,jmeter 1,jmeter 2
+/login,250,
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected export:\n%s", buffer.String())
+	}
+}
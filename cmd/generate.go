@@ -15,15 +15,18 @@
 package cmd
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/dakaraj/ptrend/dbutils"
 	"github.com/dakaraj/ptrend/templates"
 	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
 	"github.com/spf13/cobra"
@@ -33,7 +36,7 @@ import (
 var (
 	testType     string
 	outputPath   string
-	testTypeList = []string{"jmeter", "wpt"}
+	testTypeList = []string{"jmeter", "wpt", "lighthouse", "har"}
 )
 
 // Stats struct contains per-request statistic
@@ -45,12 +48,102 @@ type Stats struct {
 	Min     []float64 `json:"min"`
 	Perc90  []float64 `json:"perc90"`
 	Perc95  []float64 `json:"perc95"`
+	Perc99  []float64 `json:"perc99"`
 }
 
 // Results struct represents statistics per-request per-test
 type Results struct {
-	Tests []string `json:"tests"`
-	Stats []Stats  `json:"results"`
+	Tests   []string `json:"tests"`
+	Details []string `json:"details"`
+	Stats   []Stats  `json:"results"`
+}
+
+// TrendValues contains values of a metric per-test. Values missing for
+// a test are NaN, those are written as null, so reports tell them apart
+// from zero values
+type TrendValues []float64
+
+// newTrendValues function returns values of a given amount of tests
+// with all of them missing
+func newTrendValues(n int) TrendValues {
+	values := make(TrendValues, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+
+	return values
+}
+
+// MarshalJSON function writes missing values as null
+func (v TrendValues) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, value := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if math.IsNaN(value) {
+			b.WriteString("null")
+			continue
+		}
+		b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	b.WriteByte(']')
+
+	return b.Bytes(), nil
+}
+
+// MetricTrend struct contains values of a single metric per-test
+type MetricTrend struct {
+	Label          string      `json:"label"`
+	HigherIsBetter bool        `json:"higherIsBetter"`
+	Values         TrendValues `json:"values"`
+}
+
+// MetricResults struct represents metric values per-test
+type MetricResults struct {
+	Tests   []string      `json:"tests"`
+	Details []string      `json:"details"`
+	Metrics []MetricTrend `json:"results"`
+}
+
+// WPTMetricTrend struct contains median values of a WPT metric per-test
+// along with standard deviation of runs. Core Web Vitals also contain
+// thresholds of good and poor values
+type WPTMetricTrend struct {
+	MetricTrend
+	Std        []float64 `json:"std"`
+	Thresholds []float64 `json:"thresholds,omitempty"`
+}
+
+// WPTBreakdownTrend struct contains request totals of a domain
+// or content type per-test
+type WPTBreakdownTrend struct {
+	Label    string      `json:"label"`
+	Requests TrendValues `json:"requests"`
+	Bytes    TrendValues `json:"bytes"`
+	LoadTime TrendValues `json:"loadTime"`
+}
+
+// WPTViewResults struct contains metrics and breakdowns of a single view
+type WPTViewResults struct {
+	Metrics      []WPTMetricTrend    `json:"metrics"`
+	Domains      []WPTBreakdownTrend `json:"domain"`
+	ContentTypes []WPTBreakdownTrend `json:"content_type"`
+}
+
+// WPTResults struct represents WPT metrics and breakdowns per-view per-test
+type WPTResults struct {
+	Tests   []string                   `json:"tests"`
+	Details []string                   `json:"details"`
+	Views   map[string]*WPTViewResults `json:"views"`
+}
+
+// wptKeyMetrics contains metrics shown at the top of WPT report
+var wptKeyMetrics = []string{
+	"chromeUserTiming.LargestContentfulPaint", "chromeUserTiming.CumulativeLayoutShift",
+	"TotalBlockingTime", "TTFB", "loadTime", "firstContentfulPaint", "SpeedIndex",
+	"fullyLoaded", "bytesIn", "requestsFull",
 }
 
 func convertStatsToFloats(stringStats []string, floatStats []float64) {
@@ -64,59 +157,412 @@ func convertStatsToFloats(stringStats []string, floatStats []float64) {
 	}
 }
 
-func generateReport(cmd *cobra.Command, args []string) {
-	// defining type of the test
-	var testTypeID int
-	switch testType {
-	case "jmeter":
-		testTypeID = 1
-	case "wpt":
-		testTypeID = 2
+// writeReport function writes report page and its script into output directory
+func writeReport(page, script string, data interface{}) {
+	// marshall report data into JSON
+	byteJSON, err := json.Marshal(data)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
 
-	inputPath := args[0]
-	var err error
-	DB, err = sql.Open("sqlite3", inputPath)
-	defer DB.Close()
+	// take a template string, fill in data and write it as a file
+	t := fasttemplate.New(script, "{{", "}}")
+	mainJS := t.ExecuteString(map[string]interface{}{
+		"data": string(byteJSON),
+	})
+
+	// make directory for resuls if needed
+	if err := os.MkdirAll(outputPath, os.ModeDir|0755); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// write index.html file
+	file, err := os.Create(outputPath + "/index.html")
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
+	file.WriteString(page)
+
+	// write main.js file
+	file, _ = os.Create(outputPath + "/main.js")
+	file.WriteString(mainJS)
+}
+
+// generateLighthouseReport function writes a report with Lighthouse
+// category scores and audit values per test
+func generateLighthouseReport() {
+	tests := getTestsFromDB(DB, 3)
+	testsNumber := len(tests)
+
+	testIdx := make(map[string]int, testsNumber)
+	for i, v := range tests {
+		testIdx[v] = i
+	}
 
 	rows, err := DB.Query(`
-SELECT description
-FROM tests
-WHERE type_id = ?
-ORDER BY test_id ASC;`, testTypeID)
+SELECT t.description, l.performance, l.accessibility, l.best_practices, l.seo,
+	l.largest_contentful_paint, l.total_blocking_time,
+	l.cumulative_layout_shift, l.speed_index, l.interactive
+FROM lighthouse_statistics AS l
+JOIN tests AS t ON l.test_id = t.test_id
+WHERE t.type_id = 3
+ORDER BY t.test_id ASC;
+`)
 	defer rows.Close()
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 
-	var tests []string
+	results := MetricResults{
+		Tests:   tests,
+		Details: testSummaries(DB, tests),
+		Metrics: []MetricTrend{
+			{Label: "Performance score", HigherIsBetter: true},
+			{Label: "Accessibility score", HigherIsBetter: true},
+			{Label: "Best practices score", HigherIsBetter: true},
+			{Label: "SEO score", HigherIsBetter: true},
+			{Label: "Largest Contentful Paint, ms"},
+			{Label: "Total Blocking Time, ms"},
+			{Label: "Cumulative Layout Shift"},
+			{Label: "Speed Index, ms"},
+			{Label: "Time to Interactive, ms"},
+		},
+	}
+	for i := range results.Metrics {
+		results.Metrics[i].Values = newTrendValues(testsNumber)
+	}
+
+	var description string
+	values := make([]sql.NullFloat64, len(results.Metrics))
+	scanArgs := []interface{}{&description}
+	for i := range values {
+		scanArgs = append(scanArgs, &values[i])
+	}
 	for rows.Next() {
-		var tst string
-		rows.Scan(&tst)
-		tests = append(tests, tst)
+		if err := rows.Scan(scanArgs...); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		idx, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		for i, v := range values {
+			if v.Valid {
+				results.Metrics[i].Values[idx] = v.Float64
+			}
+		}
 	}
 
-	// ########## JMETER LOGIC ##########
+	writeReport(templates.LighthouseTemplate, templates.MetricsJS, results)
+}
+
+// harReportData contains HAR data shown by report in order, those are
+// values of "har-data" flag of export
+var harReportData = []string{"pages", "phases"}
+
+// generateHARReport function writes a report with page timings and
+// average timing phases of requests per URL per test
+func generateHARReport() {
+	tests := getTestsFromDB(DB, 4)
+	testsNumber := len(tests)
+	testIdx := make(map[string]int, testsNumber)
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	results := MetricResults{
+		Tests:   tests,
+		Details: testSummaries(DB, tests),
+	}
+	for _, data := range harReportData {
+		table := harExportTables[data]
+		rows, err := DB.Query(table.query)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		// each page or URL gets a metric per value next to each other
+		firstMetric := map[string]int{}
+		for rows.Next() {
+			var (
+				description string
+				name        string
+				values      = make([]sql.NullFloat64, len(table.fields))
+			)
+			scanArgs := []interface{}{&description, &name}
+			for i := range values {
+				scanArgs = append(scanArgs, &values[i])
+			}
+			if err := rows.Scan(scanArgs...); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			idx, ok := testIdx[description]
+			if !ok {
+				continue
+			}
+			first, ok := firstMetric[name]
+			if !ok {
+				first = len(results.Metrics)
+				firstMetric[name] = first
+				for _, field := range table.fields {
+					results.Metrics = append(results.Metrics, MetricTrend{
+						Label:  fmt.Sprintf("%s (%s)", name, field),
+						Values: newTrendValues(testsNumber),
+					})
+				}
+			}
+			for i, v := range values {
+				if v.Valid {
+					results.Metrics[first+i].Values[idx] = v.Float64
+				}
+			}
+		}
+		rows.Close()
+	}
+
+	writeReport(templates.HARTemplate, templates.MetricsJS, results)
+}
+
+// wptHigherIsBetter function tells if growing value of a WPT metric
+// is an improvement. Those are scores, the rest are timings and sizes
+func wptHigherIsBetter(name string) bool {
+	return strings.HasPrefix(name, "score_") || strings.HasPrefix(name, "lighthouse.")
+}
+
+// generateWPTReport function writes a report with median and standard
+// deviation of WPT metrics and request breakdowns per view per test
+func generateWPTReport() {
+	tests := getTestsFromDB(DB, 2)
 	testsNumber := len(tests)
+	testIdx := make(map[string]int, testsNumber)
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	results := WPTResults{
+		Tests:   tests,
+		Details: testSummaries(DB, tests),
+		Views:   map[string]*WPTViewResults{},
+	}
+	for _, view := range wptViews {
+		results.Views[view.name] = &WPTViewResults{}
+	}
+
+	rows, err := DB.Query(`
+SELECT t.description, w.view, w.statistic, w.step, w.name, w.value
+FROM wpt_metrics AS w
+	JOIN tests AS t ON w.test_id = t.test_id
+WHERE t.type_id = 2 AND w.statistic IN ('med', 'std')
+ORDER BY t.test_id ASC, w.wpt_metric_id ASC;
+`)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// metrics are grouped by view and labeled by step name if any
+	metricIdx := map[string]map[string]int{}
+	for rows.Next() {
+		var (
+			description string
+			view        string
+			statistic   string
+			step        string
+			name        string
+			value       float64
+		)
+		if err := rows.Scan(&description, &view, &statistic, &step, &name, &value); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		col, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		label := name
+		if step != "" {
+			label = fmt.Sprintf("%s (%s)", name, step)
+		}
+		if metricIdx[view] == nil {
+			metricIdx[view] = map[string]int{}
+		}
+		viewResults := results.Views[view]
+		idx, ok := metricIdx[view][label]
+		if !ok {
+			idx = len(viewResults.Metrics)
+			metricIdx[view][label] = idx
+			viewResults.Metrics = append(viewResults.Metrics, WPTMetricTrend{
+				MetricTrend: MetricTrend{
+					Label:          label,
+					HigherIsBetter: wptHigherIsBetter(name),
+					Values:         newTrendValues(testsNumber),
+				},
+				Std: make([]float64, testsNumber),
+			})
+			for _, vital := range wptWebVitals {
+				if vital.name == name {
+					viewResults.Metrics[idx].Thresholds = []float64{vital.good, vital.poor}
+				}
+			}
+		}
+		value = math.Round(value*100) / 100
+		if statistic == "std" {
+			viewResults.Metrics[idx].Std[col] = value
+		} else {
+			viewResults.Metrics[idx].Values[col] = value
+		}
+	}
+	rows.Close()
+
+	// key metrics go first, the rest are ordered by name
+	keyOrder := make(map[string]int, len(wptKeyMetrics))
+	for i, name := range wptKeyMetrics {
+		keyOrder[name] = i
+	}
+	for _, viewResults := range results.Views {
+		metrics := viewResults.Metrics
+		sort.SliceStable(metrics, func(i, j int) bool {
+			iOrder, iKey := keyOrder[metrics[i].Label]
+			jOrder, jKey := keyOrder[metrics[j].Label]
+			if iKey != jKey {
+				return iKey
+			}
+			if iKey {
+				return iOrder < jOrder
+			}
+			return metrics[i].Label < metrics[j].Label
+		})
+	}
 
 	rows, err = DB.Query(`
+SELECT t.description, b.view, b.dimension, b.name, b.requests, b.bytes, b.load_time
+FROM wpt_breakdowns AS b
+	JOIN tests AS t ON b.test_id = t.test_id
+WHERE t.type_id = 2
+ORDER BY b.name ASC, t.test_id ASC;
+`)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	type breakdownKey struct{ view, dimension, name string }
+	breakdownIdx := map[breakdownKey]int{}
+	for rows.Next() {
+		var (
+			description string
+			view        string
+			dimension   string
+			name        string
+			requests    float64
+			bytes       float64
+			loadTime    float64
+		)
+		if err := rows.Scan(&description, &view, &dimension, &name, &requests, &bytes, &loadTime); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		col, ok := testIdx[description]
+		if !ok {
+			continue
+		}
+		breakdowns := &results.Views[view].Domains
+		if dimension == "content_type" {
+			breakdowns = &results.Views[view].ContentTypes
+		}
+		key := breakdownKey{view, dimension, name}
+		idx, ok := breakdownIdx[key]
+		if !ok {
+			idx = len(*breakdowns)
+			breakdownIdx[key] = idx
+			*breakdowns = append(*breakdowns, WPTBreakdownTrend{
+				Label:    name,
+				Requests: newTrendValues(testsNumber),
+				Bytes:    newTrendValues(testsNumber),
+				LoadTime: newTrendValues(testsNumber),
+			})
+		}
+		breakdown := &(*breakdowns)[idx]
+		breakdown.Requests[col] = requests
+		breakdown.Bytes[col] = bytes
+		breakdown.LoadTime[col] = loadTime
+	}
+
+	writeReport(templates.WPTTemplate, templates.WPTJS, results)
+}
+
+func generateReport(cmd *cobra.Command, args []string) {
+	inputPath := args[0]
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// upgrading schema of DB created by earlier builds
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// defining type of the test
+	var testTypeID int
+	switch testType {
+	case "jmeter":
+		testTypeID = 1
+	case "wpt":
+		generateWPTReport()
+		return
+	case "har":
+		generateHARReport()
+		return
+	case "lighthouse":
+		generateLighthouseReport()
+		return
+	}
+
+	tests := getTestsFromDB(DB, testTypeID)
+
+	// ########## JMETER LOGIC ##########
+	testsNumber := len(tests)
+	testIdx := make(map[string]int, testsNumber)
+	for i, v := range tests {
+		testIdx[v] = i
+	}
+
+	dialect := dbutils.DialectOf(DB)
+	rows, err := DB.Query(fmt.Sprintf(`
 SELECT r.label,
-	GROUP_CONCAT(t.description),
-	GROUP_CONCAT(r.average),
-	GROUP_CONCAT(r.median),
-	GROUP_CONCAT(r.perc90),
-	GROUP_CONCAT(r.perc95),
-	GROUP_CONCAT(r.min),
-	GROUP_CONCAT(r.max)
+	%s,
+	%s,
+	%s,
+	%s,
+	%s,
+	%s,
+	%s,
+	%s
 FROM request_statistics AS r
 JOIN tests as t ON r.test_id = t.test_id
+WHERE t.type_id = ?
 GROUP BY r.label;
-`)
+`, dialect.GroupConcat("t.description", ","),
+		dialect.GroupConcat("r.average", ","),
+		dialect.GroupConcat("r.median", ","),
+		dialect.GroupConcat("r.perc90", ","),
+		dialect.GroupConcat("r.perc95", ","),
+		dialect.GroupConcat("r.perc99", ","),
+		dialect.GroupConcat("r.min", ","),
+		dialect.GroupConcat("r.max", ",")), testTypeID)
 	defer rows.Close()
 	if err != nil {
 		fmt.Println(err.Error())
@@ -137,38 +583,26 @@ GROUP BY r.label;
 			median         string
 			perc90         string
 			perc95         string
+			perc99         string
 			min            string
 			max            string
 		)
-		rows.Scan(&label, &testDecription, &average, &median, &perc90, &perc95, &min, &max)
+		rows.Scan(&label, &testDecription, &average, &median, &perc90, &perc95, &perc99, &min, &max)
 		// splitting all concatenated data into arrays
 		splitDesc := strings.Split(testDecription, ",")
-		splitAverage := strings.Split(average, ",")
-		splitMedian := strings.Split(median, ",")
-		splitPerc90 := strings.Split(perc90, ",")
-		splitPerc95 := strings.Split(perc95, ",")
-		splitMin := strings.Split(min, ",")
-		splitMax := strings.Split(max, ",")
-		// If there is no info for particular transaction in some test
-		// then fill all values with zeroes
-		if len(splitDesc) != testsNumber {
-			diff := testsNumber - len(splitDesc)
-			splitDesc = append(splitDesc, make([]string, diff)...)
-			for i, v := range tests {
-				if v != splitDesc[i] {
-					// inserting missing values
-					copy(splitDesc[i+1:], splitDesc[i:])
-					splitDesc[i] = v
-					// insert zero value at current index to each array
-					splitAverage = fillMissingStatValue(i, splitAverage)
-					splitMedian = fillMissingStatValue(i, splitMedian)
-					splitPerc90 = fillMissingStatValue(i, splitPerc90)
-					splitPerc95 = fillMissingStatValue(i, splitPerc95)
-					splitMin = fillMissingStatValue(i, splitMin)
-					splitMax = fillMissingStatValue(i, splitMax)
-				}
-			}
+		if !hasAnyTest(splitDesc, testIdx) {
+			continue
 		}
+		// If there is no info for particular transaction in some test
+		// or test is filtered out then values are placed by test order
+		// filling missing ones with zeroes
+		splitAverage := orderStatValues(splitDesc, strings.Split(average, ","), testIdx, "0")
+		splitMedian := orderStatValues(splitDesc, strings.Split(median, ","), testIdx, "0")
+		splitPerc90 := orderStatValues(splitDesc, strings.Split(perc90, ","), testIdx, "0")
+		splitPerc95 := orderStatValues(splitDesc, strings.Split(perc95, ","), testIdx, "0")
+		splitPerc99 := orderStatValues(splitDesc, strings.Split(perc99, ","), testIdx, "0")
+		splitMin := orderStatValues(splitDesc, strings.Split(min, ","), testIdx, "0")
+		splitMax := orderStatValues(splitDesc, strings.Split(max, ","), testIdx, "0")
 
 		// create struct with calculated metrics
 		requestStats := Stats{
@@ -177,6 +611,7 @@ GROUP BY r.label;
 			Median:  make([]float64, testsNumber, testsNumber),
 			Perc90:  make([]float64, testsNumber, testsNumber),
 			Perc95:  make([]float64, testsNumber, testsNumber),
+			Perc99:  make([]float64, testsNumber, testsNumber),
 			Min:     make([]float64, testsNumber, testsNumber),
 			Max:     make([]float64, testsNumber, testsNumber),
 		}
@@ -186,6 +621,7 @@ GROUP BY r.label;
 		convertStatsToFloats(splitMedian, requestStats.Median)
 		convertStatsToFloats(splitPerc90, requestStats.Perc90)
 		convertStatsToFloats(splitPerc95, requestStats.Perc95)
+		convertStatsToFloats(splitPerc99, requestStats.Perc99)
 		convertStatsToFloats(splitMin, requestStats.Min)
 		convertStatsToFloats(splitMax, requestStats.Max)
 
@@ -193,37 +629,9 @@ GROUP BY r.label;
 	}
 
 	results.Tests = tests
+	results.Details = testSummaries(DB, tests)
 
-	// marshall Results struct into JSON
-	byteJSON, err := json.Marshal(results)
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
-	}
-
-	// take a template string, fill in data and write it as a file
-	t := fasttemplate.New(templates.MainJS, "{{", "}}")
-	mainJS := t.ExecuteString(map[string]interface{}{
-		"data": string(byteJSON),
-	})
-
-	// make directory for resuls if needed
-	if err := os.MkdirAll(outputPath, os.ModeDir|0755); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
-	}
-
-	// write index.html file
-	file, err := os.Create(outputPath + "/index.html")
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
-	}
-	file.WriteString(templates.JmeterTemplate)
-
-	// write main.js file
-	file, _ = os.Create(outputPath + "/main.js")
-	file.WriteString(mainJS)
+	writeReport(templates.JmeterTemplate, templates.MainJS, results)
 }
 
 // validateGenerateArgs function validates arguments for "generate" command
@@ -233,8 +641,8 @@ func validateGenerateArgs(cmd *cobra.Command, args []string) error {
 		return errors.New("Please provide path to input DB file as a single argument")
 	}
 
-	// validate if input file exists and is not a dir
-	if fileInf, err := os.Stat(args[0]); err != nil || fileInf.IsDir() {
+	// validate if input DB exists
+	if !dbExists(args[0]) {
 		return errors.New("Input file path is invalid or file does not exist")
 	}
 
@@ -246,6 +654,11 @@ func validateGenerateArgs(cmd *cobra.Command, args []string) error {
 		return errors.New("Output path is invalid. Should not exist or be a directory")
 	}
 
+	// validate filter and order flags
+	if err := validateTestFilter(); err != nil {
+		return err
+	}
+
 	// validate source flag is valid
 	valid := false
 	for _, val := range testTypeList {
@@ -266,7 +679,10 @@ var generateCmd = &cobra.Command{
 	Use:   "generate path/to/db/file",
 	Short: "Generate a report from parsed data",
 	Long: `Generate command uses data parsed earlier to create a
-trends report`,
+trends report. Tests can be filtered and ordered by their attributes,
+which are shown as a tooltip of test column header. Report of HAR source
+shows page timings (onContentLoad and onLoad) and average timing phases
+of requests per URL`,
 	Args: validateGenerateArgs,
 	Run:  generateReport,
 }
@@ -277,4 +693,5 @@ func init() {
 	generateCmd.Flags().StringVarP(&testType, "source", "s", "jmeter",
 		fmt.Sprintf("Chose data source type for report generation: %v", testTypeList))
 	generateCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Directory to write output file into")
+	addTestFilterFlags(generateCmd)
 }
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestMarshallingTrendValues(t *testing.T) {
+	values := newTrendValues(3)
+	values[0], values[2] = 0, 1.25
+
+	data, err := json.Marshal(MetricTrend{Label: "CLS", Values: values})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"label":"CLS","higherIsBetter":false,"values":[0,null,1.25]}`
+	if string(data) != expected {
+		t.Errorf("Expected missing values to be null, got %s", data)
+	}
+}
+
+func TestGeneratingHARReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	for i, title := range []string{"home", "search"} {
+		testID, err := insertTest(DB, "har "+title, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := HARPage{Title: title}
+		page.PageTimings.OnContentLoad, page.PageTimings.OnLoad = 300, float64(500+i*100)
+		if err := insertHARPages(DB, testID, []HARPage{page}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputPath = dir
+	defer func() { outputPath = "" }()
+	generateHARReport()
+
+	script, err := ioutil.ReadFile(filepath.Join(dir, "main.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results struct {
+		Metrics []struct {
+			Label  string     `json:"label"`
+			Values []*float64 `json:"values"`
+		} `json:"results"`
+	}
+	data := strings.TrimPrefix(string(script), "let data = ")
+	if err := json.NewDecoder(strings.NewReader(data)).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, metric := range results.Metrics {
+		labels = append(labels, metric.Label)
+	}
+	expected := "home (onContentLoad), home (onLoad), search (onContentLoad), search (onLoad)"
+	if strings.Join(labels, ", ") != expected {
+		t.Fatalf("Unexpected metrics: %v", labels)
+	}
+	if onLoad := results.Metrics[3].Values; onLoad[0] != nil || onLoad[1] == nil || *onLoad[1] != 600 {
+		t.Errorf("Expected onLoad of search page only in second test, got %v", onLoad)
+	}
+}
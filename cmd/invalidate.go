@@ -0,0 +1,126 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	invalidReason string
+	invalidRevert bool
+)
+
+// invalidateTests function marks tests as invalid, so those are
+// excluded from reports, or marks them as valid again
+func invalidateTests(cmd *cobra.Command, args []string) {
+	inputPath, tests := args[0], args[1:]
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer tx.Rollback()
+
+	for _, test := range tests {
+		testID, description, err := findTest(tx, test)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		var reason interface{}
+		if invalidReason != "" {
+			reason = invalidReason
+		}
+		if invalidRevert {
+			_, err = tx.Exec(`UPDATE tests SET invalid = 0, invalid_reason = NULL WHERE test_id = ?;`, testID)
+		} else {
+			_, err = tx.Exec(`UPDATE tests SET invalid = 1, invalid_reason = ? WHERE test_id = ?;`, reason, testID)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		if invalidRevert {
+			fmt.Printf("Marked test %d as valid: %s\n", testID, description)
+		} else {
+			fmt.Printf("Invalidated test %d: %s\n", testID, description)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateInvalidateArgs function validates arguments for "invalidate" command
+func validateInvalidateArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) < 2 {
+		return errors.New("Please provide path to DB file and at least one test id or description")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate reason is not given when reverting
+	if invalidRevert && invalidReason != "" {
+		return errors.New("Reason can not be set when test is marked as valid")
+	}
+
+	return nil
+}
+
+// invalidateCmd represents the invalidate command
+var invalidateCmd = &cobra.Command{
+	Use:   `invalidate path/to/db/file "test id or description" [other tests...]`,
+	Short: "Exclude tests from reports keeping their data",
+	Long: `Mark tests identified by id or description as invalid, e.g. if
+those were run against a wrong environment. Invalidated tests stay stored,
+but are excluded from "generate", "export" and "list" commands unless
+"include-invalid" flag is used.`,
+	Args: validateInvalidateArgs,
+	Run:  invalidateTests,
+}
+
+func init() {
+	rootCmd.AddCommand(invalidateCmd)
+
+	invalidateCmd.Flags().StringVarP(&invalidReason, "reason", "r", "", "Reason of invalidation shown in test details")
+	invalidateCmd.Flags().BoolVar(&invalidRevert, "revert", false, "Mark tests as valid again")
+}
@@ -0,0 +1,210 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+var (
+	// listFormatList contains valid values for "format" flag
+	listFormatList = []string{"table", "json", "csv"}
+	listFormat     string
+)
+
+// TestSummary struct contains a test with its attributes and amount of data
+type TestSummary struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Started     string `json:"started,omitempty"`
+	Finished    string `json:"finished,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Build       string `json:"build,omitempty"`
+	Revision    string `json:"revision,omitempty"`
+	Tags        string `json:"tags,omitempty"`
+	Invalid     string `json:"invalid,omitempty"`
+	// URL, Connectivity and Browser are only known for WPT tests
+	URL          string `json:"url,omitempty"`
+	Connectivity string `json:"connectivity,omitempty"`
+	Browser      string `json:"browser,omitempty"`
+	Labels       int    `json:"labels"`
+	Samples      int    `json:"samples"`
+}
+
+// fields function returns values of a summary as strings
+// in the same order as listHeader
+func (s TestSummary) fields() []string {
+	return []string{
+		strconv.FormatInt(s.ID, 10), s.Description, s.Type, s.Started, s.Finished,
+		s.Environment, s.Build, s.Revision, s.Tags, s.Invalid,
+		s.URL, s.Connectivity, s.Browser,
+		strconv.Itoa(s.Labels), strconv.Itoa(s.Samples),
+	}
+}
+
+// listHeader contains column names of tests list
+var listHeader = []string{
+	"ID", "Description", "Type", "Started", "Finished",
+	"Environment", "Build", "Revision", "Tags", "Invalid",
+	"URL", "Connectivity", "Browser", "Labels", "Samples",
+}
+
+// getTestSummariesFromDB function returns all tests matched by filter flags
+// along with amount of labels and samples stored for them
+func getTestSummariesFromDB(DB *sql.DB) []TestSummary {
+	filter, args := testFilterSQL()
+	dialect := dbutils.DialectOf(DB)
+	order := testOrderSQL(dialect)
+	rows, err := DB.Query(fmt.Sprintf(`
+SELECT t.test_id, t.description, tt.type_description,
+	COALESCE(%s, ''),
+	COALESCE(%s, ''),
+	COALESCE(t.environment, ''), COALESCE(t.build, ''), COALESCE(t.revision, ''),
+	COALESCE((SELECT %s
+		FROM (SELECT key, value FROM test_tags WHERE test_id = t.test_id ORDER BY key) AS g), ''),
+	CASE WHEN t.invalid = 1 THEN COALESCE(NULLIF(t.invalid_reason, ''), 'yes') ELSE '' END,
+	COALESCE((SELECT url FROM wpt_tests WHERE test_id = t.test_id), ''),
+	COALESCE((SELECT connectivity FROM wpt_tests WHERE test_id = t.test_id), ''),
+	COALESCE((SELECT browser FROM wpt_tests WHERE test_id = t.test_id), ''),
+	(SELECT COUNT(DISTINCT label) FROM request_statistics WHERE test_id = t.test_id),
+	(SELECT COALESCE(SUM(samples), 0) FROM request_statistics WHERE test_id = t.test_id)
+FROM (SELECT * FROM tests WHERE 1 = 1%s) AS t
+	JOIN test_types AS tt ON t.type_id = tt.type_id
+ORDER BY %s IS NULL, %s ASC, t.test_id ASC;
+`, dialect.FormatTime("t.started"), dialect.FormatTime("t.finished"),
+		dialect.GroupConcat("g.key || '=' || g.value", " "), filter, order, order), args...)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	var summaries []TestSummary
+	for rows.Next() {
+		var s TestSummary
+		err := rows.Scan(&s.ID, &s.Description, &s.Type, &s.Started, &s.Finished,
+			&s.Environment, &s.Build, &s.Revision, &s.Tags, &s.Invalid,
+			&s.URL, &s.Connectivity, &s.Browser, &s.Labels, &s.Samples)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		summaries = append(summaries, s)
+	}
+
+	return summaries
+}
+
+// listTests function prints tests stored in DB in a selected format
+func listTests(cmd *cobra.Command, args []string) {
+	inputPath := args[0]
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// upgrading schema of DB created by earlier builds
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	summaries := getTestSummariesFromDB(DB)
+
+	switch listFormat {
+	case "json":
+		if summaries == nil {
+			summaries = []TestSummary{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summaries); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	case "csv":
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write(listHeader)
+		for _, s := range summaries {
+			writer.Write(s.fields())
+		}
+		writer.Flush()
+	default:
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(listHeader, "\t"))
+		for _, s := range summaries {
+			fmt.Fprintln(writer, strings.Join(s.fields(), "\t"))
+		}
+		writer.Flush()
+	}
+}
+
+// validateListArgs function validates arguments for "list" command
+func validateListArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 1 {
+		return errors.New("Please provide path to input DB file as a single argument")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate format flag is valid
+	if !isOneOf(listFormat, listFormatList) {
+		return fmt.Errorf("Format is not one of the following: %v", listFormatList)
+	}
+
+	// validate filter and order flags
+	return validateTestFilter()
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list path/to/db/file",
+	Short: "List tests stored in a database",
+	Long: `List tests stored in a database with their type, period,
+attributes, amount of labels and total amount of samples. WPT tests
+are listed with tested URL, connectivity and browser, those finish
+at completion time of WPT test.
+Tests can be filtered and ordered by their attributes. Invalidated
+tests are only listed with "include-invalid" flag.`,
+	Args: validateListArgs,
+	Run:  listTests,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().StringVarP(&listFormat, "format", "f", "table", fmt.Sprintf("Output format: %v", listFormatList))
+	addTestFilterFlags(listCmd)
+}
@@ -0,0 +1,396 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// mergeConflictList contains valid values for "on-conflict" flag
+	mergeConflictList = []string{"skip", "rename", "fail"}
+	mergeConflict     string
+)
+
+// mergeTables contains tables with data of a test along with their
+// surrogate key column, which is generated anew in target DB
+var mergeTables = []struct {
+	name string
+	key  string
+}{
+	{"request_statistics", "request_id"},
+	{"response_codes", "code_id"},
+	{"wpt_tests", ""},
+	{"wpt_metrics", "wpt_metric_id"},
+	{"wpt_breakdowns", "wpt_breakdown_id"},
+	{"lighthouse_statistics", "lighthouse_id"},
+	{"har_pages", "page_id"},
+	{"har_timings", "timing_id"},
+	{"test_tags", ""},
+	{"raw_samples", ""},
+	{"request_percentiles", ""},
+}
+
+// sourceRow struct contains column values of a single row of source DB
+type sourceRow struct {
+	columns []string
+	values  []interface{}
+}
+
+// get function returns value of a column
+func (r sourceRow) get(column string) interface{} {
+	for i, c := range r.columns {
+		if c == column {
+			return r.values[i]
+		}
+	}
+
+	return nil
+}
+
+// int64Value function returns value of an integer column, which
+// drivers return either as a number or as a text
+func (r sourceRow) int64Value(column string) (int64, error) {
+	switch v := r.get(column).(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	}
+
+	return 0, fmt.Errorf("Column %q of source DB has invalid integer value %v", column, r.get(column))
+}
+
+// stringValue function returns value of a text column
+func (r sourceRow) stringValue(column string) (string, error) {
+	switch v := r.get(column).(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+
+	return "", fmt.Errorf("Column %q of source DB has invalid text value %v", column, r.get(column))
+}
+
+// readRows function reads all rows returned by a query, converting values
+// so those are stored in target DB the same way parsers store them
+func readRows(DB dbExecutor, query string, args ...interface{}) ([]sourceRow, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var result []sourceRow
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			switch v := value.(type) {
+			case time.Time:
+				values[i] = v.UTC().Format(dbTimeLayout)
+			case []byte:
+				// drivers may return text as bytes, which would be
+				// stored as a blob not equal to any text
+				if name := types[i].DatabaseTypeName(); name != "BLOB" && name != "BYTEA" {
+					values[i] = string(v)
+				}
+			case bool:
+				// PostgreSQL keeps flags as numbers
+				values[i] = 0
+				if v {
+					values[i] = 1
+				}
+			}
+		}
+		result = append(result, sourceRow{columns, values})
+	}
+
+	return result, rows.Err()
+}
+
+// insertRow function inserts a row into a table of target DB skipping
+// its surrogate key column and replacing its test id
+func insertRow(DB dbExecutor, table, key string, row sourceRow, testID int64) error {
+	var (
+		columns []string
+		values  []interface{}
+	)
+	for i, column := range row.columns {
+		switch column {
+		case key:
+			continue
+		case "test_id":
+			values = append(values, testID)
+		default:
+			values = append(values, row.values[i])
+		}
+		columns = append(columns, column)
+	}
+	_, err := DB.Exec(fmt.Sprintf(`INSERT INTO %s (%s) VALUES (?%s);`,
+		table, strings.Join(columns, ", "), strings.Repeat(", ?", len(columns)-1)), values...)
+
+	return err
+}
+
+// uniqueDescription function returns a description which is not used
+// by any test of target DB by appending a number to it
+func uniqueDescription(DB dbExecutor, description string) (string, error) {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", description, n)
+		if exists, err := testExists(DB, candidate); err != nil || !exists {
+			return candidate, err
+		}
+	}
+}
+
+// testExists function checks if target DB has a test with a description
+func testExists(DB dbExecutor, description string) (bool, error) {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM tests WHERE description = ?;`, description).Scan(&count)
+
+	return count > 0, err
+}
+
+// mergeDB function copies all tests of a source DB along with their data
+// into target DB and returns amounts of merged and skipped tests
+func mergeDB(source *sql.DB, target dbExecutor, sourcePath string) (int, int, error) {
+	tests, err := readRows(source, `SELECT * FROM tests ORDER BY test_id ASC;`)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var merged, skipped int
+	for _, test := range tests {
+		sourceID, err := test.int64Value("test_id")
+		if err != nil {
+			return merged, skipped, err
+		}
+		description, err := test.stringValue("description")
+		if err != nil {
+			return merged, skipped, err
+		}
+		exists, err := testExists(target, description)
+		if err != nil {
+			return merged, skipped, err
+		}
+		if exists {
+			switch mergeConflict {
+			case "skip":
+				fmt.Printf("Skipped test %d of %s: %s already exists\n", sourceID, sourcePath, description)
+				skipped++
+				continue
+			case "rename":
+				renamed, err := uniqueDescription(target, description)
+				if err != nil {
+					return merged, skipped, err
+				}
+				for i, column := range test.columns {
+					if column == "description" {
+						test.values[i] = renamed
+					}
+				}
+				description = renamed
+			default:
+				return merged, skipped, fmt.Errorf("Test %q of %s already exists in target DB", description, sourcePath)
+			}
+		}
+
+		if err := insertRow(target, "tests", "test_id", test, 0); err != nil {
+			return merged, skipped, err
+		}
+		testID, err := testIDOf(target, description)
+		if err != nil {
+			return merged, skipped, err
+		}
+		for _, table := range mergeTables {
+			rows, err := readRows(source,
+				fmt.Sprintf(`SELECT * FROM %s WHERE test_id = ?;`, table.name), sourceID)
+			if err != nil {
+				return merged, skipped, err
+			}
+			for _, row := range rows {
+				if err := insertRow(target, table.name, table.key, row, testID); err != nil {
+					return merged, skipped, err
+				}
+			}
+		}
+		fmt.Printf("Merged test %d of %s as %d: %s\n", sourceID, sourcePath, testID, description)
+		merged++
+	}
+
+	return merged, skipped, nil
+}
+
+// openSourceDB function opens a source DB making sure its schema
+// is the one supported by this build. Source DBs are only read, so
+// those are never upgraded in place
+func openSourceDB(path string) (*sql.DB, error) {
+	source, err := dbutils.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := source.Ping(); err != nil {
+		source.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	// databases created before schema was versioned have no version table
+	version, err := dbutils.CurrentVersion(source)
+	if err != nil {
+		version = 0
+	}
+	if version > dbutils.SchemaVersion {
+		source.Close()
+		return nil, fmt.Errorf("%s: database schema version %d is newer than supported version %d, please upgrade ptrend",
+			path, version, dbutils.SchemaVersion)
+	}
+	if version < dbutils.SchemaVersion {
+		source.Close()
+		return nil, fmt.Errorf("%s: database schema version %d is older than supported version %d, please upgrade it first, e.g. with \"list\" command",
+			path, version, dbutils.SchemaVersion)
+	}
+
+	return source, nil
+}
+
+// mergeDBs function imports tests from source DBs into target DB.
+// Nothing is imported if merging of any test fails
+func mergeDBs(cmd *cobra.Command, args []string) {
+	outputPath, sourcePaths := args[0], args[1:]
+	var err error
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer tx.Rollback()
+
+	var merged, skipped int
+	for _, path := range sourcePaths {
+		source, err := openSourceDB(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		m, s, err := mergeDB(source, tx, path)
+		source.Close()
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		merged += m
+		skipped += s
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Merged %d tests, skipped %d tests\n", merged, skipped)
+}
+
+// validateMergeArgs function validates arguments for "merge" command
+func validateMergeArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) < 2 {
+		return errors.New("Please provide path to target DB file and at least one source DB file")
+	}
+
+	// validate if output file is not a dir
+	target, err := os.Stat(args[0])
+	if err == nil && target.IsDir() {
+		return errors.New("Output file path is invalid")
+	}
+
+	// validate if source DBs exist and differ from target
+	for _, val := range args[1:] {
+		if !dbExists(val) {
+			return errors.New("Input file path is invalid or file does not exist")
+		}
+		if fileInf, err := os.Stat(val); err == nil && target != nil && os.SameFile(target, fileInf) {
+			return errors.New("Source DB file should differ from target DB file")
+		}
+	}
+
+	// validate conflict policy is valid
+	if !isOneOf(mergeConflict, mergeConflictList) {
+		return fmt.Errorf("Conflict policy is not one of the following: %v", mergeConflictList)
+	}
+
+	return nil
+}
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge path/to/target/db/file path/to/source/db/file [other sources...]",
+	Short: "Import tests from other databases",
+	Long: `Import tests along with all of their statistics from one or more
+source databases into a target database, e.g. to combine databases kept
+by different CI pipelines. Test ids are assigned anew in target database.
+A test with a description already used in target database is skipped,
+renamed by appending a number to it, or makes the whole merge fail
+depending on "on-conflict" flag.
+
+Source databases are only read, those should have the same schema
+version as this build, so databases created by earlier builds have to be
+upgraded first, e.g. by running "list" command on them.`,
+	Args: validateMergeArgs,
+	Run:  mergeDBs,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVarP(&mergeConflict, "on-conflict", "c", "skip", fmt.Sprintf("Policy for tests with descriptions existing in target DB: %v", mergeConflictList))
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestMergingDatabases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var dbs []*sql.DB
+	for _, name := range []string{"source.db", "target.db"} {
+		db, err := dbutils.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if err := dbutils.Initialize(db); err != nil {
+			t.Fatal(err)
+		}
+		testID, err := insertTest(db, "nightly", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := insertRequestStats(db, testID, []RequestStats{{Label: "home", Samples: 10}}); err != nil {
+			t.Fatal(err)
+		}
+		dbs = append(dbs, db)
+	}
+	source, target := dbs[0], dbs[1]
+
+	mergeConflict = "fail"
+	if _, _, err := mergeDB(source, target, "source.db"); err == nil {
+		t.Error("Expected an error for existing test description")
+	}
+
+	mergeConflict = "rename"
+	merged, skipped, err := mergeDB(source, target, "source.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != 1 || skipped != 0 {
+		t.Errorf("Expected 1 merged and 0 skipped tests, got %d and %d", merged, skipped)
+	}
+	var samples int
+	target.QueryRow(`
+SELECT rs.samples
+FROM request_statistics AS rs
+	JOIN tests AS t ON rs.test_id = t.test_id
+WHERE t.description = 'nightly (2)';`).Scan(&samples)
+	if samples != 10 {
+		t.Errorf("Expected statistics of renamed test to be merged, got %d samples", samples)
+	}
+}
+
+func TestMergingLegacyDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "legacy.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.Exec(`CREATE TABLE test_types (type_id INTEGER PRIMARY KEY AUTOINCREMENT, type_description VARCHAR(64));`)
+
+	if source, err := openSourceDB(path); err == nil {
+		source.Close()
+		t.Fatal("Expected an error for source DB created before schema was versioned")
+	}
+	var tables int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version';`).Scan(&tables)
+	if tables != 0 {
+		t.Error("Expected source DB to be left untouched")
+	}
+}
+
+func TestMergingNullAndBinaryValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var dbs []*sql.DB
+	for _, name := range []string{"source.db", "target.db"} {
+		db, err := dbutils.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if err := dbutils.Initialize(db); err != nil {
+			t.Fatal(err)
+		}
+		dbs = append(dbs, db)
+	}
+	source, target := dbs[0], dbs[1]
+
+	// metadata of a test is NULL unless it is provided
+	testID, err := insertTest(source, "lighthouse", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.Exec(`UPDATE tests SET invalid = 1 WHERE test_id = ?;`, testID)
+	source.Exec(`INSERT INTO lighthouse_statistics (test_id, performance) VALUES (?, 0.9);`, testID)
+	samples := []rawSample{{Label: "home", Elapsed: 100, Success: true}}
+	if err := insertRawSamples(source, testID, samples); err != nil {
+		t.Fatal(err)
+	}
+
+	mergeConflict = "fail"
+	if _, _, err := mergeDB(source, target, "source.db"); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		environment   sql.NullString
+		invalid       bool
+		performance   sql.NullFloat64
+		accessibility sql.NullFloat64
+		data          []byte
+	)
+	err = target.QueryRow(`
+SELECT t.environment, t.invalid, l.performance, l.accessibility, r.data
+FROM tests AS t
+	JOIN lighthouse_statistics AS l ON t.test_id = l.test_id
+	JOIN raw_samples AS r ON t.test_id = r.test_id
+WHERE t.description = 'lighthouse';`).Scan(&environment, &invalid, &performance, &accessibility, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if environment.Valid || !invalid || performance.Float64 != 0.9 || accessibility.Valid {
+		t.Errorf("Unexpected values merged: %v, %v, %v, %v", environment, invalid, performance, accessibility)
+	}
+	if decoded, err := decodeRawSamples(data); err != nil || len(decoded) != 1 || decoded[0] != samples[0] {
+		t.Errorf("Expected raw samples to be merged as is, got %+v (%v)", decoded, err)
+	}
+	var kind string
+	target.QueryRow(`SELECT typeof(data) FROM raw_samples;`).Scan(&kind)
+	if kind != "blob" {
+		t.Errorf("Expected raw samples to be kept as blob, got %s", kind)
+	}
+}
+
+func TestReadingSourceValues(t *testing.T) {
+	// PostgreSQL driver returns values of some columns as bytes
+	row := sourceRow{
+		columns: []string{"test_id", "description", "environment"},
+		values:  []interface{}{[]byte("7"), []byte("nightly"), nil},
+	}
+	if id, err := row.int64Value("test_id"); err != nil || id != 7 {
+		t.Errorf("Expected test id 7, got %d (%v)", id, err)
+	}
+	if description, err := row.stringValue("description"); err != nil || description != "nightly" {
+		t.Errorf("Expected description nightly, got %q (%v)", description, err)
+	}
+	if _, err := row.stringValue("environment"); err == nil {
+		t.Error("Expected an error for NULL value")
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+// dbTimeLayout is a layout of date and time values stored in DB
+const dbTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	// testEnvironment, testBuild and testRevision contain attributes of
+	// a parsed test, those are used as filters by reporting commands
+	testEnvironment string
+	testBuild       string
+	testRevision    string
+	// testTagList contains raw "key=value" values of "tag" flag
+	testTagList []string
+	testTags    map[string]string
+	// testSince and testUntil limit start time of reported tests
+	testSince string
+	testUntil string
+	// testOrder contains a value of "order-by" flag
+	testOrder string
+	// includeInvalid makes invalidated tests part of reports
+	includeInvalid bool
+	// logPeriod contains start and end time of a test seen in its log
+	logPeriod testPeriod
+)
+
+// testOrderColumns maps valid values of "order-by" flag to SQL expressions
+var testOrderColumns = map[string]func(dialect dbutils.Dialect) string{
+	"id":          orderByColumn("test_id"),
+	"description": orderByColumn("description"),
+	"started":     orderByColumn("started"),
+	"finished":    orderByColumn("finished"),
+	"duration":    testDurationSQL,
+	"environment": orderByColumn("environment"),
+	"build":       orderByColumn("build"),
+	"revision":    orderByColumn("revision"),
+}
+
+// orderByColumn function returns an expression of a column,
+// which is the same for all backends
+func orderByColumn(column string) func(dialect dbutils.Dialect) string {
+	return func(dbutils.Dialect) string {
+		return column
+	}
+}
+
+// testOrderSQL function returns an expression tests are ordered by
+// according to "order-by" flag
+func testOrderSQL(dialect dbutils.Dialect) string {
+	order, ok := testOrderColumns[testOrder]
+	if !ok {
+		order = testOrderColumns["id"]
+	}
+
+	return order(dialect)
+}
+
+// testDurationSQL function returns an expression of test duration in seconds
+func testDurationSQL(dialect dbutils.Dialect) string {
+	return "ROUND(" + dialect.SecondsBetween("started", "finished") + ")"
+}
+
+// testPeriod struct contains start and end time of a test
+type testPeriod struct {
+	started  time.Time
+	finished time.Time
+}
+
+// observe function extends period so it includes a given time span
+func (p *testPeriod) observe(start, end time.Time) {
+	if start.IsZero() {
+		return
+	}
+	if end.Before(start) {
+		end = start
+	}
+	if p.started.IsZero() || start.Before(p.started) {
+		p.started = start
+	}
+	if end.After(p.finished) {
+		p.finished = end
+	}
+}
+
+// periodValue function returns time formatted for DB or nil if it is not known
+func periodValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.UTC().Format(dbTimeLayout)
+}
+
+// addTestMetadataFlags function adds flags setting test attributes
+// to a parse command
+func addTestMetadataFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&testEnvironment, "environment", "", "Environment the test was run against")
+	cmd.Flags().StringVar(&testBuild, "build", "", "Version or build number of tested application")
+	cmd.Flags().StringVar(&testRevision, "revision", "", "VCS revision of tested application")
+	cmd.Flags().StringArrayVar(&testTagList, "tag", nil, "Tag of the test in key=value form, can be repeated")
+}
+
+// addTestFilterFlags function adds flags filtering and ordering tests
+// to a reporting command
+func addTestFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&testEnvironment, "environment", "", "Only include tests run against an environment")
+	cmd.Flags().StringVar(&testBuild, "build", "", "Only include tests of a version or build number")
+	cmd.Flags().StringVar(&testRevision, "revision", "", "Only include tests of a VCS revision")
+	cmd.Flags().StringArrayVar(&testTagList, "tag", nil, "Only include tests having a tag in key=value form, can be repeated")
+	cmd.Flags().StringVar(&testSince, "since", "", "Only include tests started at or after a date (YYYY-MM-DD[ HH:MM:SS])")
+	cmd.Flags().StringVar(&testUntil, "until", "", "Only include tests started before a date (YYYY-MM-DD[ HH:MM:SS])")
+	cmd.Flags().StringVar(&testOrder, "order-by", "id", fmt.Sprintf("Order tests by one of: %v", testOrderList()))
+	cmd.Flags().BoolVar(&includeInvalid, "include-invalid", false, "Include invalidated tests")
+}
+
+// testOrderList function returns sorted valid values of "order-by" flag
+func testOrderList() []string {
+	list := make([]string, 0, len(testOrderColumns))
+	for name := range testOrderColumns {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+// parseTags function converts "key=value" pairs into a map
+func parseTags(list []string) (map[string]string, error) {
+	tags := make(map[string]string, len(list))
+	for _, tag := range list {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("Tag %q should be in key=value form", tag)
+		}
+		tags[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return tags, nil
+}
+
+// parseDate function parses date passed to a filter flag
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{dbTimeLayout, "2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Date %q should be in YYYY-MM-DD[ HH:MM:SS] form", value)
+}
+
+// validateTestMetadata function validates flags of test attributes
+func validateTestMetadata() error {
+	tags, err := parseTags(testTagList)
+	if err != nil {
+		return err
+	}
+	testTags = tags
+
+	return nil
+}
+
+// validateTestFilter function validates flags filtering and ordering tests
+func validateTestFilter() error {
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+	for _, date := range []string{testSince, testUntil} {
+		if date == "" {
+			continue
+		}
+		if _, err := parseDate(date); err != nil {
+			return err
+		}
+	}
+	if _, ok := testOrderColumns[testOrder]; !ok {
+		return fmt.Errorf("Order is not one of the following: %v", testOrderList())
+	}
+
+	return nil
+}
+
+// insertTestMetadata function stores attributes set with flags
+// and a period the test was running in for a test
+func insertTestMetadata(DB dbExecutor, testID int64, period testPeriod) error {
+	nullable := func(value string) interface{} {
+		if value == "" {
+			return nil
+		}
+		return value
+	}
+	_, err := DB.Exec(`
+UPDATE tests
+SET started = ?, finished = ?, environment = ?, build = ?, revision = ?
+WHERE test_id = ?;`, periodValue(period.started), periodValue(period.finished),
+		nullable(testEnvironment), nullable(testBuild), nullable(testRevision), testID)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range testTags {
+		_, err := DB.Exec(`INSERT INTO test_tags (test_id, key, value) VALUES (?, ?, ?);`,
+			testID, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// testFilterSQL function returns conditions selecting tests matched by
+// filter flags along with their arguments
+func testFilterSQL() (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if !includeInvalid {
+		conditions = append(conditions, "invalid = 0")
+	}
+	for _, filter := range []struct {
+		column string
+		value  string
+	}{
+		{"environment", testEnvironment},
+		{"build", testBuild},
+		{"revision", testRevision},
+	} {
+		if filter.value != "" {
+			conditions = append(conditions, filter.column+" = ?")
+			args = append(args, filter.value)
+		}
+	}
+	if testSince != "" {
+		since, _ := parseDate(testSince)
+		conditions = append(conditions, "started >= ?")
+		args = append(args, since.Format(dbTimeLayout))
+	}
+	if testUntil != "" {
+		until, _ := parseDate(testUntil)
+		conditions = append(conditions, "started < ?")
+		args = append(args, until.Format(dbTimeLayout))
+	}
+
+	keys := make([]string, 0, len(testTags))
+	for key := range testTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		conditions = append(conditions, `test_id IN (
+	SELECT test_id FROM test_tags WHERE key = ? AND value = ?)`)
+		args = append(args, key, testTags[key])
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return " AND " + strings.Join(conditions, " AND "), args
+}
+
+// TestDetails struct contains attributes of a test shown in reports
+type TestDetails struct {
+	Description string
+	Started     string
+	Finished    string
+	Duration    string
+	Environment string
+	Build       string
+	Revision    string
+	Tags        string
+	Invalid     string
+	// URL, Location, Connectivity, Browser and Completed are only known
+	// for WPT tests
+	URL          string
+	Location     string
+	Connectivity string
+	Browser      string
+	Completed    string
+}
+
+// summary function returns attributes of a test as a single line
+func (d TestDetails) summary() string {
+	var parts []string
+	for _, attr := range []struct {
+		name  string
+		value string
+	}{
+		{"started", d.Started},
+		{"duration", d.Duration},
+		{"url", d.URL},
+		{"location", d.Location},
+		{"connectivity", d.Connectivity},
+		{"browser", d.Browser},
+		{"completed", d.Completed},
+		{"environment", d.Environment},
+		{"build", d.Build},
+		{"revision", d.Revision},
+		{"tags", d.Tags},
+		{"invalid", d.Invalid},
+	} {
+		if attr.value != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", attr.name, attr.value))
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// getTestDetailsFromDB function returns attributes of given tests
+// in the same order
+func getTestDetailsFromDB(DB *sql.DB, tests []string) []TestDetails {
+	dialect := dbutils.DialectOf(DB)
+	details := make([]TestDetails, len(tests))
+	for i, description := range tests {
+		var (
+			started, finished            sql.NullString
+			environment, build, revision sql.NullString
+			invalid                      string
+			duration                     sql.NullFloat64
+			url, location                sql.NullString
+			connectivity, browser        sql.NullString
+			completed                    sql.NullString
+		)
+		err := DB.QueryRow(`
+SELECT `+dialect.FormatTime("started")+`, `+dialect.FormatTime("finished")+`,
+	`+testDurationSQL(dialect)+`, environment, build, revision,
+	CASE WHEN invalid = 1 THEN COALESCE(NULLIF(invalid_reason, ''), 'yes') ELSE '' END,
+	w.url, w.location, w.connectivity, w.browser, `+dialect.FormatTime("w.completed")+`
+FROM tests
+	LEFT JOIN wpt_tests AS w ON tests.test_id = w.test_id
+WHERE description = ?;`, description).Scan(&started, &finished, &duration,
+			&environment, &build, &revision, &invalid,
+			&url, &location, &connectivity, &browser, &completed)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		details[i] = TestDetails{
+			Description:  description,
+			Started:      started.String,
+			Finished:     finished.String,
+			Environment:  environment.String,
+			Build:        build.String,
+			Revision:     revision.String,
+			Invalid:      invalid,
+			URL:          url.String,
+			Location:     location.String,
+			Connectivity: connectivity.String,
+			Browser:      browser.String,
+			Completed:    completed.String,
+		}
+		if duration.Valid {
+			details[i].Duration = (time.Duration(duration.Float64) * time.Second).String()
+		}
+
+		rows, err := DB.Query(`
+SELECT g.key, g.value
+FROM test_tags AS g
+	JOIN tests AS t ON g.test_id = t.test_id
+WHERE t.description = ?
+ORDER BY g.key ASC;`, description)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		var tags []string
+		for rows.Next() {
+			var key, value string
+			rows.Scan(&key, &value)
+			tags = append(tags, key+"="+value)
+		}
+		rows.Close()
+		details[i].Tags = strings.Join(tags, " ")
+	}
+
+	return details
+}
+
+// testSummaries function returns one line attributes of given tests
+func testSummaries(DB *sql.DB, tests []string) []string {
+	summaries := make([]string, len(tests))
+	for i, d := range getTestDetailsFromDB(DB, tests) {
+		summaries[i] = d.summary()
+	}
+
+	return summaries
+}
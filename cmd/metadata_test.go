@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsingTestMetadata(t *testing.T) {
+	tags, err := parseTags([]string{"team=core", " region = eu ", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags["team"] != "core" || tags["region"] != "eu" || tags["empty"] != "" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+	if _, err := parseTags([]string{"broken"}); err == nil {
+		t.Error("Expected an error for tag without value")
+	}
+
+	var period testPeriod
+	start := time.Date(2018, 10, 18, 10, 0, 0, 0, time.UTC)
+	period.observe(start.Add(time.Minute), start.Add(2*time.Minute))
+	period.observe(start, start.Add(time.Second))
+	if !period.started.Equal(start) || !period.finished.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Unexpected period: %v - %v", period.started, period.finished)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+// abGnuplotHeader is a first line of a file written by "ab -g"
+const abGnuplotHeader = "starttime\tseconds\tctime\tdtime\tttime\twait"
+
+var abLabel string
+
+// parseABGnuplot function reads TSV file written by "ab -g" and calculates
+// statistics from total time ("ttime") of every request
+func parseABGnuplot(input io.Reader, rs *RequestStats) error {
+	var samples []int
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		// skipping header and empty lines
+		if len(fields) != 6 || fields[0] == "starttime" {
+			continue
+		}
+		ttime, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return fmt.Errorf("Invalid ttime value %q", fields[4])
+		}
+		samples = append(samples, ttime)
+		if seconds, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			start := time.Unix(seconds, 0)
+			logPeriod.observe(start, start.Add(time.Duration(ttime)*time.Millisecond))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return errors.New("No samples found in gnuplot file")
+	}
+
+	rs.Samples = len(samples)
+	calculateStats(samples, rs)
+
+	return nil
+}
+
+// parseABReport function reads text report printed by ab. Average, min and max
+// are taken from "Total" line of connection times table, percentiles
+// are taken from "Percentage of the requests served within" table
+func parseABReport(input io.Reader, rs *RequestStats) error {
+	var (
+		foundTotal  bool
+		percentiles = map[string]float64{}
+	)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "Document Path:") && len(fields) > 2:
+			if rs.Label == "" {
+				rs.Label = fields[2]
+			}
+		case strings.HasPrefix(line, "Complete requests:") && len(fields) > 2:
+			rs.Samples, _ = strconv.Atoi(fields[2])
+		case strings.HasPrefix(line, "Total:") && len(fields) >= 6:
+			// Total: min mean [+/-sd] median max
+			min, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return fmt.Errorf("Invalid connection times line %q", line)
+			}
+			avg, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return fmt.Errorf("Invalid connection times line %q", line)
+			}
+			max, err := strconv.Atoi(fields[5])
+			if err != nil {
+				return fmt.Errorf("Invalid connection times line %q", line)
+			}
+			rs.Min, rs.Average, rs.Max = min, avg, max
+			foundTotal = true
+		case len(fields) >= 2 && strings.HasSuffix(fields[0], "%"):
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				continue
+			}
+			percentiles[strings.TrimSuffix(fields[0], "%")] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if !foundTotal {
+		return errors.New("Connection times table is missing in ab report")
+	}
+	if rs.Samples == 0 {
+		return errors.New("Number of complete requests is missing in ab report")
+	}
+	rs.Median = percentiles["50"]
+	rs.Perc90 = percentiles["90"]
+	rs.Perc95 = percentiles["95"]
+	rs.Perc99 = percentiles["99"]
+
+	return nil
+}
+
+// parseABFile function detects a format of ab output file
+// and parses it accordingly
+func parseABFile(inputPath string) (RequestStats, error) {
+	rs := RequestStats{Label: abLabel}
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		return rs, err
+	}
+	defer inputFile.Close()
+
+	reader := bufio.NewReader(inputFile)
+	firstLine, err := reader.Peek(len(abGnuplotHeader))
+	if err == nil && string(firstLine) == abGnuplotHeader {
+		err = parseABGnuplot(reader, &rs)
+	} else {
+		err = parseABReport(reader, &rs)
+	}
+	if rs.Label == "" {
+		rs.Label = "ab"
+	}
+
+	return rs, err
+}
+
+// parseABFiles function parses ab output storing results into db file
+func parseABFiles(cmd *cobra.Command, args []string) {
+	description, outputPath, inputPath := args[0], args[1], args[2]
+
+	rs, err := parseABFile(inputPath)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// ab results are stored as a load test, so they trend along with Jmeter ones
+	err = ingest(DB, func(tx *sql.Tx) error {
+		lastID, err := insertTestWithMetadata(tx, description, 1, logPeriod)
+		if err != nil {
+			return err
+		}
+
+		return insertRequestStats(tx, lastID, []RequestStats{rs})
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateParseABArgs function validates arguments for "parseab" command
+func validateParseABArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 3 {
+		return errors.New(
+			"Please provide a unique description and two path arguments",
+		)
+	}
+
+	// validate if db file is not a dir
+	if fileInf, err := os.Stat(args[1]); err == nil && fileInf.IsDir() {
+		return errors.New("Output file path is invalid")
+	}
+
+	// validate if input file exists and is not a dir
+	if fileInf, err := os.Stat(args[2]); err != nil || fileInf.IsDir() {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseabCmd represents the parseab command
+var parseabCmd = &cobra.Command{
+	Use:   `parseab "unique test description" path/to/db/file path/to/ab/output`,
+	Short: "Parses Apache Bench output into SQLite database",
+	Long: `Parses Apache Bench text report or gnuplot file (written with "-g" flag)
+from a provided path and populates database with new data.
+Single run is stored as a load test with a single label.`,
+	Args: validateParseABArgs,
+	Run:  parseABFiles,
+}
+
+func init() {
+	rootCmd.AddCommand(parseabCmd)
+
+	parseabCmd.Flags().StringVarP(&abLabel, "label", "l", "", `Label to store results under (default is document path or "ab")`)
+	addTestMetadataFlags(parseabCmd)
+}
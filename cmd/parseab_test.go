@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+const abReport = `Server Software:        nginx
+Server Hostname:        localhost
+Server Port:            80
+
+Document Path:          /index.html
+Document Length:        612 bytes
+
+Concurrency Level:      10
+Time taken for tests:   0.254 seconds
+Complete requests:      1000
+Failed requests:        0
+
+Connection Times (ms)
+              min  mean[+/-sd] median   max
+Connect:        0    1   0.3      1       3
+Processing:     0    1   0.4      1       4
+Waiting:        0    1   0.4      1       4
+Total:          1    2   0.6      2       6
+
+Percentage of the requests served within a certain time (ms)
+  50%      2
+  66%      2
+  75%      3
+  80%      3
+  90%      3
+  95%      4
+  98%      4
+  99%      5
+ 100%      6 (longest request)
+`
+
+func TestParsingABReport(t *testing.T) {
+	rs := RequestStats{}
+	if err := parseABReport(strings.NewReader(abReport), &rs); err != nil {
+		t.Fatalf("Failed to parse ab report: %v", err)
+	}
+	expected := RequestStats{
+		Label:   "/index.html",
+		Samples: 1000,
+		Average: 2,
+		Median:  2,
+		Perc90:  3,
+		Perc95:  4,
+		Perc99:  5,
+		Min:     1,
+		Max:     6,
+	}
+	if rs != expected {
+		t.Errorf("Expected %+v, got %+v", expected, rs)
+	}
+}
+
+func TestParsingABGnuplot(t *testing.T) {
+	input := abGnuplotHeader + `
+Thu Oct 18 10:00:00 2018	1539856800	0	3	4	3
+Thu Oct 18 10:00:00 2018	1539856800	1	5	6	5
+Thu Oct 18 10:00:00 2018	1539856800	0	1	2	1
+`
+	rs := RequestStats{}
+	if err := parseABGnuplot(strings.NewReader(input), &rs); err != nil {
+		t.Fatalf("Failed to parse ab gnuplot file: %v", err)
+	}
+	if rs.Samples != 3 || rs.Min != 2 || rs.Max != 6 || rs.Median != 4 {
+		t.Errorf("Unexpected statistics calculated: %+v", rs)
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+const (
+	// artilleryEndpointPrefix is a prefix of metrics-by-endpoint plugin
+	// summaries and counters in Artillery 2.x reports
+	artilleryEndpointPrefix = "plugins.metrics-by-endpoint."
+	// artilleryResponseTime is a name of aggregate response time summary
+	// in Artillery 2.x reports
+	artilleryResponseTime = "http.response_time"
+)
+
+var artilleryAggregateLabel string
+
+// ArtilleryReport struct contains aggregate part of Artillery JSON report
+type ArtilleryReport struct {
+	Aggregate ArtilleryAggregate `json:"aggregate"`
+}
+
+// ArtilleryAggregate struct contains aggregate statistics
+// of both Artillery 1.x and 2.x report formats
+type ArtilleryAggregate struct {
+	// Artillery 1.x fields
+	RequestsCompleted int                         `json:"requestsCompleted"`
+	Latency           *ArtillerySummary           `json:"latency"`
+	Codes             map[string]int              `json:"codes"`
+	Errors            map[string]int              `json:"errors"`
+	CustomStats       map[string]ArtillerySummary `json:"customStats"`
+	// Artillery 1.x and 2.x fields
+	Counters  map[string]int              `json:"counters"`
+	Summaries map[string]ArtillerySummary `json:"summaries"`
+	// Artillery 2.x fields, unix time in milliseconds
+	FirstMetricAt int64 `json:"firstMetricAt"`
+	LastMetricAt  int64 `json:"lastMetricAt"`
+}
+
+// ArtillerySummary struct contains response time statistics.
+// Mean and p90 are only reported by Artillery 2.x
+type ArtillerySummary struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+// requestStats function converts summary into statistics of a label
+func (s ArtillerySummary) requestStats(label string, samples int) RequestStats {
+	if s.Count > 0 {
+		samples = s.Count
+	}
+
+	return RequestStats{
+		Label:   label,
+		Samples: samples,
+		Average: s.Mean,
+		Median:  s.Median,
+		Perc90:  s.P90,
+		Perc95:  s.P95,
+		Perc99:  s.P99,
+		Min:     int(math.Round(s.Min)),
+		Max:     int(math.Round(s.Max)),
+	}
+}
+
+// addCode function adds amount of responses with a code to a label
+func addCode(codes map[string]map[string]int, label, code string, count int) {
+	if codes[label] == nil {
+		codes[label] = map[string]int{}
+	}
+	codes[label][code] += count
+}
+
+// sumCodes function returns total amount of responses of a label
+func sumCodes(codes map[string]map[string]int, label string) (total int) {
+	for _, count := range codes[label] {
+		total += count
+	}
+
+	return total
+}
+
+// parseArtilleryReport function maps aggregate and per-endpoint summaries
+// of Artillery report to request statistics and response codes per label
+func parseArtilleryReport(input io.Reader) ([]RequestStats, map[string]map[string]int, error) {
+	var report ArtilleryReport
+	if err := json.NewDecoder(input).Decode(&report); err != nil {
+		return nil, nil, fmt.Errorf("Failed to decode Artillery report: %v", err)
+	}
+	aggregate := report.Aggregate
+	if aggregate.FirstMetricAt > 0 {
+		logPeriod.observe(time.Unix(0, aggregate.FirstMetricAt*int64(time.Millisecond)),
+			time.Unix(0, aggregate.LastMetricAt*int64(time.Millisecond)))
+	}
+
+	var (
+		stats []RequestStats
+		codes = map[string]map[string]int{}
+	)
+
+	// collecting response codes and errors from counters
+	for name, count := range aggregate.Counters {
+		switch {
+		case strings.HasPrefix(name, "http.codes."):
+			addCode(codes, artilleryAggregateLabel, strings.TrimPrefix(name, "http.codes."), count)
+		case strings.HasPrefix(name, "errors."):
+			addCode(codes, artilleryAggregateLabel, strings.TrimPrefix(name, "errors."), count)
+		case strings.HasPrefix(name, artilleryEndpointPrefix):
+			// per-endpoint counters look like "<prefix><endpoint>.codes.<code>"
+			endpoint := strings.TrimPrefix(name, artilleryEndpointPrefix)
+			for _, kind := range []string{".codes.", ".errors."} {
+				if idx := strings.LastIndex(endpoint, kind); idx > 0 {
+					addCode(codes, endpoint[:idx], endpoint[idx+len(kind):], count)
+					break
+				}
+			}
+		}
+	}
+	for code, count := range aggregate.Codes {
+		addCode(codes, artilleryAggregateLabel, code, count)
+	}
+	for code, count := range aggregate.Errors {
+		addCode(codes, artilleryAggregateLabel, code, count)
+	}
+
+	// aggregate statistics
+	if summary, ok := aggregate.Summaries[artilleryResponseTime]; ok {
+		stats = append(stats, summary.requestStats(artilleryAggregateLabel, 0))
+	} else if aggregate.Latency != nil {
+		stats = append(stats, aggregate.Latency.requestStats(
+			artilleryAggregateLabel, aggregate.RequestsCompleted))
+	}
+
+	// per-endpoint statistics
+	endpoints := map[string]ArtillerySummary{}
+	for name, summary := range aggregate.Summaries {
+		if strings.HasPrefix(name, artilleryEndpointPrefix+"response_time.") {
+			endpoints[strings.TrimPrefix(name, artilleryEndpointPrefix+"response_time.")] = summary
+		}
+	}
+	for name, summary := range aggregate.CustomStats {
+		endpoints[name] = summary
+	}
+	names := make([]string, 0, len(endpoints))
+	for name := range endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats = append(stats, endpoints[name].requestStats(name, sumCodes(codes, name)))
+	}
+
+	if len(stats) == 0 {
+		return nil, nil, errors.New("Provided file does not contain Artillery aggregate statistics")
+	}
+
+	return stats, codes, nil
+}
+
+// parseArtilleryFiles function parses input file storing results into db file
+func parseArtilleryFiles(cmd *cobra.Command, args []string) {
+	ignorePattern = regexp.MustCompile(ignorePatternString)
+	description, outputPath, inputPath := args[0], args[1], args[2]
+
+	inputFile, err := os.Open(inputPath)
+	defer inputFile.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	stats, codes, err := parseArtilleryReport(inputFile)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// dropping labels matched by ignore pattern
+	if ignorePatternString != "" {
+		filtered := stats[:0]
+		for _, rs := range stats {
+			if !ignorePattern.MatchString(rs.Label) {
+				filtered = append(filtered, rs)
+			}
+		}
+		stats = filtered
+		for label := range codes {
+			if ignorePattern.MatchString(label) {
+				delete(codes, label)
+			}
+		}
+	}
+
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// Artillery results are stored as a load test, so they trend along with Jmeter ones
+	err = ingest(DB, func(tx *sql.Tx) error {
+		lastID, err := insertTestWithMetadata(tx, description, 1, logPeriod)
+		if err != nil {
+			return err
+		}
+		if err := insertRequestStats(tx, lastID, stats); err != nil {
+			return err
+		}
+
+		return insertResponseCodes(tx, lastID, codes)
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateParseArtilleryArgs function validates arguments for "parseartillery" command
+func validateParseArtilleryArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 3 {
+		return errors.New(
+			"Please provide a unique description and two path arguments",
+		)
+	}
+
+	// validate if db file is not a dir
+	if fileInf, err := os.Stat(args[1]); err == nil && fileInf.IsDir() {
+		return errors.New("Output file path is invalid")
+	}
+
+	// validate if input file exists and is not a dir
+	if fileInf, err := os.Stat(args[2]); err != nil || fileInf.IsDir() {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate provided ignore pattern
+	if _, err := regexp.Compile(ignorePatternString); err != nil {
+		return errors.New("Provided ignore pattern is invalid")
+	}
+
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseartilleryCmd represents the parseartillery command
+var parseartilleryCmd = &cobra.Command{
+	Use:   `parseartillery "unique test description" path/to/db/file path/to/report.json`,
+	Short: "Parses Artillery JSON report into SQLite database",
+	Long: `Parses Artillery JSON report (written with "--output" flag) from
+a provided path and populates database with aggregate and per-endpoint
+statistics. Per-endpoint statistics require metrics-by-endpoint plugin.
+Results are stored as a load test, so they trend along with Jmeter ones.`,
+	Args: validateParseArtilleryArgs,
+	Run:  parseArtilleryFiles,
+}
+
+func init() {
+	rootCmd.AddCommand(parseartilleryCmd)
+
+	parseartilleryCmd.Flags().StringVarP(&artilleryAggregateLabel, "aggregate-label", "a", "All requests", "Label to store aggregate statistics under")
+	parseartilleryCmd.Flags().StringVarP(&ignorePatternString, "ignore-pattern", "i", "", "Label regex pattern that will be ignored by parser")
+	addTestMetadataFlags(parseartilleryCmd)
+}
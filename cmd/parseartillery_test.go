@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsingArtilleryReport(t *testing.T) {
+	input := `{"aggregate": {
+	"counters": {
+		"http.codes.200": 95,
+		"http.codes.500": 3,
+		"errors.ETIMEDOUT": 2,
+		"vusers.codes.x": 1,
+		"plugins.metrics-by-endpoint./api/items.codes.200": 95,
+		"plugins.metrics-by-endpoint./api/items.codes.500": 3
+	},
+	"summaries": {
+		"http.response_time": {"min": 10, "max": 900, "count": 98, "mean": 120.5,
+			"median": 100, "p90": 300, "p95": 450.1, "p99": 800},
+		"plugins.metrics-by-endpoint.response_time./api/items": {"min": 10,
+			"max": 900, "count": 98, "mean": 120.5, "median": 100, "p90": 300,
+			"p95": 450.1, "p99": 800}
+	}
+}}`
+	artilleryAggregateLabel = "All requests"
+	stats, codes, err := parseArtilleryReport(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse Artillery report: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Label != "All requests" || stats[1].Label != "/api/items" {
+		t.Fatalf("Unexpected labels parsed: %+v", stats)
+	}
+	if stats[1].Samples != 98 || stats[1].Perc99 != 800 || stats[1].Max != 900 {
+		t.Errorf("Unexpected endpoint statistics: %+v", stats[1])
+	}
+	if codes["All requests"]["ETIMEDOUT"] != 2 || codes["/api/items"]["500"] != 3 {
+		t.Errorf("Unexpected response codes: %v", codes)
+	}
+	if len(codes) != 2 {
+		t.Errorf("Expected counters of other plugins to be ignored, got %v", codes)
+	}
+}
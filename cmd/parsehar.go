@@ -0,0 +1,318 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+// harIDSegment matches URL path segments that identify a resource
+// (numbers, UUIDs and long hex hashes) and are replaced during normalization
+var harIDSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+var harKeepQuery bool
+
+// HARFile struct contains parts of HAR 1.2 file that are stored in DB
+type HARFile struct {
+	Log struct {
+		Pages   []HARPage  `json:"pages"`
+		Entries []HAREntry `json:"entries"`
+	} `json:"log"`
+}
+
+// HARPage struct represents a page with its load timings
+type HARPage struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	PageTimings struct {
+		OnContentLoad float64 `json:"onContentLoad"`
+		OnLoad        float64 `json:"onLoad"`
+	} `json:"pageTimings"`
+}
+
+// HAREntry struct represents a single request with its timings
+type HAREntry struct {
+	StartedDateTime string  `json:"startedDateTime"`
+	Time            float64 `json:"time"`
+	Request         struct {
+		URL string `json:"url"`
+	} `json:"request"`
+	Timings HARTimings `json:"timings"`
+}
+
+// HARTimings struct contains timings of request phases.
+// Phases that do not apply to a request have -1 value
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// add function sums up timings of two requests ignoring not applicable phases
+func (t *HARTimings) add(other HARTimings) {
+	t.Blocked += math.Max(other.Blocked, 0)
+	t.DNS += math.Max(other.DNS, 0)
+	t.Connect += math.Max(other.Connect, 0)
+	t.SSL += math.Max(other.SSL, 0)
+	t.Send += math.Max(other.Send, 0)
+	t.Wait += math.Max(other.Wait, 0)
+	t.Receive += math.Max(other.Receive, 0)
+}
+
+// average function returns average timings for a given amount of requests
+func (t HARTimings) average(count int) HARTimings {
+	avg := func(v float64) float64 {
+		return math.Round(v/float64(count)*100) / 100
+	}
+
+	return HARTimings{
+		Blocked: avg(t.Blocked),
+		DNS:     avg(t.DNS),
+		Connect: avg(t.Connect),
+		SSL:     avg(t.SSL),
+		Send:    avg(t.Send),
+		Wait:    avg(t.Wait),
+		Receive: avg(t.Receive),
+	}
+}
+
+// normalizeURL function brings request URLs to a common form, so same
+// resources requested with different ids or parameters are grouped together
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	if !harKeepQuery {
+		u.RawQuery = ""
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if harIDSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	return strings.Replace(u.String(), "%7Bid%7D", "{id}", -1)
+}
+
+// parseHARFiles function parses input file storing results into db file
+func parseHARFiles(cmd *cobra.Command, args []string) {
+	ignorePattern = regexp.MustCompile(ignorePatternString)
+	description, outputPath, inputPath := args[0], args[1], args[2]
+
+	inputFile, err := os.Open(inputPath)
+	defer inputFile.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	var har HARFile
+	if err := json.NewDecoder(inputFile).Decode(&har); err != nil {
+		fmt.Printf("Failed to decode HAR file: %v\n", err)
+		os.Exit(1)
+	}
+	if len(har.Log.Entries) == 0 {
+		fmt.Println("Provided HAR file does not contain any entries")
+		os.Exit(1)
+	}
+
+	// grouping entries by normalized URL
+	var (
+		elapsed = map[string][]int{}
+		timings = map[string]*HARTimings{}
+	)
+	for _, entry := range har.Log.Entries {
+		label := normalizeURL(entry.Request.URL)
+		if ignorePatternString != "" && ignorePattern.MatchString(label) {
+			continue
+		}
+		if _, ok := timings[label]; !ok {
+			timings[label] = &HARTimings{}
+		}
+		timings[label].add(entry.Timings)
+		elapsed[label] = append(elapsed[label], int(math.Round(entry.Time)))
+		if started, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime); err == nil {
+			logPeriod.observe(started, started.Add(time.Duration(entry.Time*float64(time.Millisecond))))
+		}
+	}
+
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// inserting new test into db getting row id in return
+	err = ingest(DB, func(tx *sql.Tx) error {
+		lastID, err := insertTestWithMetadata(tx, description, 4, logPeriod)
+		if err != nil {
+			return err
+		}
+		if err := insertHARPages(tx, lastID, har.Log.Pages); err != nil {
+			return err
+		}
+
+		return insertHARTimings(tx, lastID, elapsed, timings)
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// insertHARPages function stores timings of every page of a HAR file
+func insertHARPages(tx dbExecutor, testID int64, pages []HARPage) error {
+	for _, page := range pages {
+		name := page.Title
+		if name == "" {
+			name = page.ID
+		}
+		_, err := tx.Exec(`
+INSERT INTO har_pages (
+	test_id, page, on_content_load, on_load
+) VALUES (
+	?, ?, ?, ?
+);`, testID, name, math.Max(page.PageTimings.OnContentLoad, 0),
+			math.Max(page.PageTimings.OnLoad, 0))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertHARTimings function stores average timing phases of requests
+// per URL along with their total times as request statistics
+func insertHARTimings(tx dbExecutor, testID int64, elapsed map[string][]int, timings map[string]*HARTimings) error {
+	labels := make([]string, 0, len(elapsed))
+	for label := range elapsed {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	// preparing an insert statement
+	insertStatement, err := tx.Prepare(`
+INSERT INTO har_timings (
+	test_id, url, requests, blocked, dns, connect, ssl, send, wait, receive
+) VALUES (
+	?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);`)
+	if err != nil {
+		return err
+	}
+	defer insertStatement.Close()
+
+	stats := make([]RequestStats, 0, len(labels))
+	for _, label := range labels {
+		count := len(elapsed[label])
+		avg := timings[label].average(count)
+		_, err := insertStatement.Exec(testID, label, count, avg.Blocked, avg.DNS,
+			avg.Connect, avg.SSL, avg.Send, avg.Wait, avg.Receive)
+		if err != nil {
+			return err
+		}
+
+		// total request times are stored as request statistics,
+		// so they can be trended with existing report
+		rs := RequestStats{Label: label, Samples: count}
+		calculateStats(elapsed[label], &rs)
+		stats = append(stats, rs)
+	}
+
+	return insertRequestStats(tx, testID, stats)
+}
+
+// validateParseHARArgs function validates arguments for "parsehar" command
+func validateParseHARArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 3 {
+		return errors.New(
+			"Please provide a unique description and two path arguments",
+		)
+	}
+
+	// validate if db file is not a dir
+	if fileInf, err := os.Stat(args[1]); err == nil && fileInf.IsDir() {
+		return errors.New("Output file path is invalid")
+	}
+
+	// validate if input file exists and is not a dir
+	if fileInf, err := os.Stat(args[2]); err != nil || fileInf.IsDir() {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate provided ignore pattern
+	if _, err := regexp.Compile(ignorePatternString); err != nil {
+		return errors.New("Provided ignore pattern is invalid")
+	}
+
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseharCmd represents the parsehar command
+var parseharCmd = &cobra.Command{
+	Use:   `parsehar "unique test description" path/to/db/file path/to/input/file`,
+	Short: "Parses HAR file into SQLite database",
+	Long: `Parses HAR 1.2 file exported from browser or proxy and populates
+database with page load timings and per-request timings grouped
+by normalized URL. Query string, fragment and id-like path segments
+are stripped from URLs during normalization.`,
+	Args: validateParseHARArgs,
+	Run:  parseHARFiles,
+}
+
+func init() {
+	rootCmd.AddCommand(parseharCmd)
+
+	parseharCmd.Flags().BoolVarP(&harKeepQuery, "keep-query", "q", false, "Keep query string when normalizing request URLs")
+	parseharCmd.Flags().StringVarP(&ignorePatternString, "ignore-pattern", "i", "", "URL regex pattern that will be ignored by parser")
+	addTestMetadataFlags(parseharCmd)
+}
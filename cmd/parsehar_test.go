@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestNormalizingURL(t *testing.T) {
+	cases := map[string]string{
+		"https://Example.com/api/users/42?page=1#top":                           "https://example.com/api/users/{id}",
+		"https://example.com/static/app.js?v=3":                                 "https://example.com/static/app.js",
+		"https://example.com/orders/0f8fad5b-d9cb-469f-a165-70867728950e/items": "https://example.com/orders/{id}/items",
+	}
+	for input, expected := range cases {
+		if got := normalizeURL(input); got != expected {
+			t.Errorf("Expected %q to be normalized to %q, got %q", input, expected, got)
+		}
+	}
+}
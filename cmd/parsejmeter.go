@@ -25,7 +25,7 @@ import (
 	"regexp"
 	"sort"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/dakaraj/ptrend/dbutils"
 	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
@@ -45,6 +45,7 @@ type RequestStats struct {
 	Median  float64
 	Perc90  float64
 	Perc95  float64
+	Perc99  float64
 	Min     int
 	Max     int
 }
@@ -53,12 +54,23 @@ type RequestStats struct {
 // then matches label to a provided pattern via "ignore-pattern" flag.
 // If label is not matched then parse duration as int and put data into records map
 func parseRecord(record []string) {
+	// raw samples are kept before filtering, so those can be recomputed
+	// with other patterns
+	if keepRaw {
+		rawRecords = append(rawRecords, newRawSample(record))
+	}
 	label, elapsed := record[2], record[1]
 	if ignorePatternString != "" && ignorePattern.MatchString(label) {
 		return
 	}
 	parsedElapsed, _ := strconv.Atoi(elapsed)
 	records[label] = append(records[label], parsedElapsed)
+
+	// timestamps are in milliseconds unless log is configured otherwise
+	if timestamp, err := strconv.ParseInt(record[0], 10, 64); err == nil {
+		start := time.Unix(0, timestamp*int64(time.Millisecond))
+		logPeriod.observe(start, start.Add(time.Duration(parsedElapsed)*time.Millisecond))
+	}
 }
 
 // calculatePercentile function calculates perentile for values slice provided
@@ -87,6 +99,7 @@ func calculateStats(stats []int, rs *RequestStats) {
 		median float64
 		perc90 float64
 		perc95 float64
+		perc99 float64
 	)
 
 	for _, v := range stats {
@@ -97,6 +110,7 @@ func calculateStats(stats []int, rs *RequestStats) {
 	median = calculatePercentile(stats, 50)
 	perc90 = calculatePercentile(stats, 90)
 	perc95 = calculatePercentile(stats, 95)
+	perc99 = calculatePercentile(stats, 99)
 
 	rs.Average = avg
 	rs.Min = min
@@ -104,16 +118,15 @@ func calculateStats(stats []int, rs *RequestStats) {
 	rs.Median = median
 	rs.Perc90 = perc90
 	rs.Perc95 = perc95
+	rs.Perc99 = perc99
 }
 
 // parseJmeterFiles function parses input file storing results into db file
 func parseJmeterFiles(cmd *cobra.Command, args []string) {
 	ignorePattern = regexp.MustCompile(ignorePatternString)
 	description, outputPath, inputPaths := args[0], args[1], args[2:]
-	// removing all commas as those are used for concatenation later
-	description = strings.Replace(description, ",", "", -1)
 	var err error
-	DB, err = sql.Open("sqlite3", outputPath)
+	DB, err = dbutils.Open(outputPath)
 	defer DB.Close()
 	if err != nil {
 		fmt.Println(err.Error())
@@ -156,43 +169,33 @@ func parseJmeterFiles(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// inserting new test into db getting row id in return
-	res, err := DB.Exec(`
-INSERT INTO tests (
-	description, type_id
-) VALUES (
-	?, 1
-);`, description)
-	if err != nil {
-		// stop process if description is not unique
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			fmt.Println("Provided test description is not unique")
-		} else {
-			fmt.Println(err.Error())
-		}
-		os.Exit(1)
-	}
-	lastID, _ := res.LastInsertId()
-
-	// preparing an insert statement
-	insertStatement, _ := DB.Prepare(`
-INSERT INTO request_statistics (
-	test_id, label, samples, average, median, perc90, perc95, min, max
-) VALUES (
-	?, ?, ?, ?, ?, ?, ?, ?, ?
-);`)
-	for req, stats := range records {
+	stats := make([]RequestStats, 0, len(records))
+	for req, samples := range records {
 		rs := RequestStats{}
 		rs.Label = req
-		rs.Samples = len(stats)
-		calculateStats(stats, &rs)
-		// insert data in db row by row
-		_, err := insertStatement.Exec(lastID, rs.Label, rs.Samples, rs.Average,
-			rs.Median, rs.Perc90, rs.Perc95, rs.Min, rs.Max)
+		rs.Samples = len(samples)
+		calculateStats(samples, &rs)
+		stats = append(stats, rs)
+	}
+
+	// inserting new test into db getting row id in return
+	err = ingest(DB, func(tx *sql.Tx) error {
+		lastID, err := insertTestWithMetadata(tx, description, 1, logPeriod)
 		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
+			return err
 		}
+		if err := insertRequestStats(tx, lastID, stats); err != nil {
+			return err
+		}
+		if keepRaw {
+			return insertRawSamples(tx, lastID, rawRecords)
+		}
+
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
 }
 
@@ -227,6 +230,11 @@ func validateParseJmeterArgs(cmd *cobra.Command, args []string) error {
 		return errors.New("Provided ignore pattern is invalid")
 	}
 
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -235,7 +243,9 @@ var parsejmeterCmd = &cobra.Command{
 	Use:   `parsejmeter "unique test description" path/to/db/file path/to/input/file [other/input/files...]`,
 	Short: "Parses Jmeter log file into SQLite database",
 	Long: `Parses Jmeter log file from a provided path and populates
-database with new data.`,
+database with new data. With "keep-raw" flag every sample (timestamp,
+label, elapsed, success and response code) is stored compressed along
+with statistics, including samples matched by ignore pattern.`,
 	Args: validateParseJmeterArgs,
 	Run:  parseJmeterFiles,
 }
@@ -246,4 +256,6 @@ func init() {
 	parsejmeterCmd.Flags().StringVarP(&delimiter, "delimiter", "d", ",", "Single character to be used as delimiter")
 	parsejmeterCmd.Flags().BoolVarP(&header, "field-names", "f", false, "Use if input file contains a header line with field names")
 	parsejmeterCmd.Flags().StringVarP(&ignorePatternString, "ignore-pattern", "i", "", "Label regex pattern that will be ignored by parser")
+	parsejmeterCmd.Flags().BoolVar(&keepRaw, "keep-raw", false, "Store every sample of the log for later recomputation")
+	addTestMetadataFlags(parsejmeterCmd)
 }
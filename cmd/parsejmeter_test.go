@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestSkippingIgnoredJmeterLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := `timeStamp~elapsed~label~responseCode~success
+1539856800000~120~login~200~true
+1539856801000~80~login~200~true
+1539856802000~300~TC checkout~200~true
+1539856803000~40~OPTIONS /api~204~true
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "test-input.csv"), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{
+		"SomeTest",
+		filepath.Join(dir, "somefile.db"),
+		filepath.Join(dir, "test-input.csv"),
+	}
+	header = true
+	delimiter = "~"
+	ignorePatternString = `^(TC |OPTIONS |chunk\.)`
+	records = map[string][]int{}
+	defer func() {
+		header, delimiter, ignorePatternString = false, ",", ""
+		records = map[string][]int{}
+	}()
+	if err := validateParseJmeterArgs(parsejmeterCmd, args); err != nil {
+		t.Fatalf("Provided arguments are invalid: %v", err)
+	}
+	parseJmeterFiles(parsejmeterCmd, args)
+
+	db, err := dbutils.Open(args[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var (
+		labels, samples int
+		average         float64
+	)
+	db.QueryRow(`SELECT COUNT(*), SUM(samples), MAX(average) FROM request_statistics;`).
+		Scan(&labels, &samples, &average)
+	if labels != 1 || samples != 2 || average != 100 {
+		t.Errorf("Expected ignored labels to be skipped, got %d labels, %d samples, %v average",
+			labels, samples, average)
+	}
+}
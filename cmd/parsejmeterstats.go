@@ -0,0 +1,235 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+// dashboardTotalLabel is a key of overall statistics in statistics.json
+const dashboardTotalLabel = "Total"
+
+// dashboardPercentiles contains percentile values configured for
+// dashboard with "aggregate_rpt_pct1", "aggregate_rpt_pct2"
+// and "aggregate_rpt_pct3" properties
+var dashboardPercentiles = make([]int, 3)
+
+// DashboardStatistics struct contains per-transaction aggregates
+// written by Jmeter HTML dashboard generator
+type DashboardStatistics struct {
+	Transaction   string  `json:"transaction"`
+	SampleCount   int     `json:"sampleCount"`
+	MeanResTime   float64 `json:"meanResTime"`
+	MedianResTime float64 `json:"medianResTime"`
+	MinResTime    float64 `json:"minResTime"`
+	MaxResTime    float64 `json:"maxResTime"`
+	Pct1ResTime   float64 `json:"pct1ResTime"`
+	Pct2ResTime   float64 `json:"pct2ResTime"`
+	Pct3ResTime   float64 `json:"pct3ResTime"`
+}
+
+// setPercentile function puts percentile value into a field of statistics
+// that corresponds to configured percentile. Returns false if there is none
+func setPercentile(rs *RequestStats, percentile int, value float64) bool {
+	value = math.Round(value*100) / 100
+	switch percentile {
+	case 50:
+		rs.Median = value
+	case 90:
+		rs.Perc90 = value
+	case 95:
+		rs.Perc95 = value
+	case 99:
+		rs.Perc99 = value
+	default:
+		return false
+	}
+
+	return true
+}
+
+// parseDashboardStatistics function maps statistics.json contents
+// to request statistics per transaction
+func parseDashboardStatistics(input io.Reader) ([]RequestStats, error) {
+	var decoded map[string]DashboardStatistics
+	if err := json.NewDecoder(input).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("Failed to decode statistics file: %v", err)
+	}
+
+	labels := make([]string, 0, len(decoded))
+	for label := range decoded {
+		if label == dashboardTotalLabel {
+			continue
+		}
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	stats := make([]RequestStats, 0, len(labels))
+	for _, label := range labels {
+		ds := decoded[label]
+		if ds.Transaction != "" {
+			label = ds.Transaction
+		}
+		if ignorePatternString != "" && ignorePattern.MatchString(label) {
+			continue
+		}
+		rs := RequestStats{
+			Label:   label,
+			Samples: ds.SampleCount,
+			Average: math.Round(ds.MeanResTime*100) / 100,
+			Median:  math.Round(ds.MedianResTime*100) / 100,
+			Min:     int(math.Round(ds.MinResTime)),
+			Max:     int(math.Round(ds.MaxResTime)),
+		}
+		pctValues := []float64{ds.Pct1ResTime, ds.Pct2ResTime, ds.Pct3ResTime}
+		for i, percentile := range dashboardPercentiles {
+			setPercentile(&rs, percentile, pctValues[i])
+		}
+		stats = append(stats, rs)
+	}
+
+	if len(stats) == 0 {
+		return nil, errors.New("Provided file does not contain any transaction statistics")
+	}
+
+	return stats, nil
+}
+
+// parseJmeterStatsFiles function parses statistics.json storing results into db file
+func parseJmeterStatsFiles(cmd *cobra.Command, args []string) {
+	ignorePattern = regexp.MustCompile(ignorePatternString)
+	description, outputPath, inputPath := args[0], args[1], args[2]
+
+	inputFile, err := os.Open(inputPath)
+	defer inputFile.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// warn about percentiles that do not have a matching column
+	for i, percentile := range dashboardPercentiles {
+		if !setPercentile(&RequestStats{}, percentile, 0) {
+			fmt.Printf("Percentile %d (pct%d) has no matching column and will not be stored\n",
+				percentile, i+1)
+		}
+	}
+
+	stats, err := parseDashboardStatistics(inputFile)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// inserting new test into db getting row id in return
+	err = ingest(DB, func(tx *sql.Tx) error {
+		lastID, err := insertTestWithMetadata(tx, description, 1, logPeriod)
+		if err != nil {
+			return err
+		}
+
+		return insertRequestStats(tx, lastID, stats)
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateParseJmeterStatsArgs function validates arguments for "parsejmeterstats" command
+func validateParseJmeterStatsArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 3 {
+		return errors.New(
+			"Please provide a unique description and two path arguments",
+		)
+	}
+
+	// validate if db file is not a dir
+	if fileInf, err := os.Stat(args[1]); err == nil && fileInf.IsDir() {
+		return errors.New("Output file path is invalid")
+	}
+
+	// validate if input file exists and is not a dir
+	if fileInf, err := os.Stat(args[2]); err != nil || fileInf.IsDir() {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate provided percentiles are in a valid range
+	for i, percentile := range dashboardPercentiles {
+		if percentile <= 0 || percentile > 100 {
+			return fmt.Errorf("Value of pct%d should be between 1 and 100", i+1)
+		}
+	}
+
+	// validate provided ignore pattern
+	if _, err := regexp.Compile(ignorePatternString); err != nil {
+		return errors.New("Provided ignore pattern is invalid")
+	}
+
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parsejmeterstatsCmd represents the parsejmeterstats command
+var parsejmeterstatsCmd = &cobra.Command{
+	Use:   `parsejmeterstats "unique test description" path/to/db/file path/to/statistics.json`,
+	Short: "Parses Jmeter HTML dashboard statistics into SQLite database",
+	Long: `Parses statistics.json file written by Jmeter HTML dashboard generator
+("-e -o" flags) from a provided path and populates database with new data.
+Percentile columns are mapped according to dashboard configured percentiles,
+so those should match values used when the dashboard was generated.`,
+	Args: validateParseJmeterStatsArgs,
+	Run:  parseJmeterStatsFiles,
+}
+
+func init() {
+	rootCmd.AddCommand(parsejmeterstatsCmd)
+
+	parsejmeterstatsCmd.Flags().IntVar(&dashboardPercentiles[0], "pct1", 90, "Value of aggregate_rpt_pct1 dashboard property")
+	parsejmeterstatsCmd.Flags().IntVar(&dashboardPercentiles[1], "pct2", 95, "Value of aggregate_rpt_pct2 dashboard property")
+	parsejmeterstatsCmd.Flags().IntVar(&dashboardPercentiles[2], "pct3", 99, "Value of aggregate_rpt_pct3 dashboard property")
+	parsejmeterstatsCmd.Flags().StringVarP(&ignorePatternString, "ignore-pattern", "i", "", "Label regex pattern that will be ignored by parser")
+	addTestMetadataFlags(parsejmeterstatsCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsingDashboardStatistics(t *testing.T) {
+	defaults := dashboardPercentiles
+	dashboardPercentiles = []int{99, 75, 95}
+	defer func() { dashboardPercentiles = defaults }()
+
+	input := `{
+	"Total": {"transaction": "Total", "sampleCount": 30, "meanResTime": 150},
+	"search": {
+		"transaction": "search", "sampleCount": 20, "meanResTime": 123.456,
+		"medianResTime": 100, "minResTime": 10.4, "maxResTime": 899.6,
+		"pct1ResTime": 850.123, "pct2ResTime": 300, "pct3ResTime": 700.5
+	},
+	"login": {"transaction": "login", "sampleCount": 10, "medianResTime": 50}
+}`
+	stats, err := parseDashboardStatistics(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Label != "login" || stats[1].Label != "search" {
+		t.Fatalf("Expected Total row to be skipped, got %+v", stats)
+	}
+
+	expected := RequestStats{
+		Label: "search", Samples: 20, Average: 123.46, Median: 100,
+		Perc90: 0, Perc95: 700.5, Perc99: 850.12, Min: 10, Max: 900,
+	}
+	if stats[1] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats[1])
+	}
+
+	if _, err := parseDashboardStatistics(strings.NewReader(`{"Total": {}}`)); err == nil {
+		t.Error("Expected an error for statistics without transactions")
+	}
+}
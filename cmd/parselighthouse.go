@@ -0,0 +1,209 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+// LighthouseReport struct contains parts of Lighthouse JSON report
+// that are stored in DB
+type LighthouseReport struct {
+	RequestedURL string `json:"requestedUrl"`
+	FinalURL     string `json:"finalUrl"`
+	FetchTime    string `json:"fetchTime"`
+	Timing       struct {
+		Total float64 `json:"total"`
+	} `json:"timing"`
+	Categories map[string]struct {
+		Score *float64 `json:"score"`
+	} `json:"categories"`
+	Audits map[string]struct {
+		NumericValue *float64 `json:"numericValue"`
+	} `json:"audits"`
+}
+
+// lighthouseCategories contains category ids in the order of DB columns
+var lighthouseCategories = []string{"performance", "accessibility", "best-practices", "seo"}
+
+// lighthouseAudits contains audit ids in the order of DB columns
+var lighthouseAudits = []string{
+	"largest-contentful-paint",
+	"total-blocking-time",
+	"cumulative-layout-shift",
+	"speed-index",
+	"interactive",
+}
+
+// categoryScore function returns category score on a 0-100 scale
+// or nil if category is missing in report, e.g. was not run
+func (r *LighthouseReport) categoryScore(id string) *float64 {
+	category, ok := r.Categories[id]
+	if !ok || category.Score == nil {
+		return nil
+	}
+	score := math.Round(*category.Score * 100)
+
+	return &score
+}
+
+// auditValue function returns numeric value of an audit
+// or nil if audit is missing in report
+func (r *LighthouseReport) auditValue(id string) *float64 {
+	audit, ok := r.Audits[id]
+	if !ok || audit.NumericValue == nil {
+		return nil
+	}
+	value := math.Round(*audit.NumericValue*1000) / 1000
+
+	return &value
+}
+
+// decodeLighthouseReport function decodes Lighthouse JSON report
+func decodeLighthouseReport(r io.Reader) (LighthouseReport, error) {
+	var report LighthouseReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return report, fmt.Errorf("Failed to decode Lighthouse report: %v", err)
+	}
+	if len(report.Categories) == 0 && len(report.Audits) == 0 {
+		return report, errors.New("Provided file is not a Lighthouse JSON report")
+	}
+
+	return report, nil
+}
+
+// parseLighthouseFiles function parses input file storing results into db file
+func parseLighthouseFiles(cmd *cobra.Command, args []string) {
+	outputPath, inputPath := args[0], args[1]
+
+	inputFile, err := os.Open(inputPath)
+	defer inputFile.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	report, err := decodeLighthouseReport(inputFile)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	url := report.FinalURL
+	if url == "" {
+		url = report.RequestedURL
+	}
+	description := fmt.Sprintf("%s (%s)", url, report.FetchTime)
+	if fetchTime, err := time.Parse(time.RFC3339, report.FetchTime); err == nil {
+		logPeriod.observe(fetchTime, fetchTime.Add(time.Duration(report.Timing.Total)*time.Millisecond))
+	}
+
+	// inserting new test into db getting row id in return
+	err = ingest(DB, func(tx *sql.Tx) error {
+		lastID, err := insertTestWithMetadata(tx, description, 3, logPeriod)
+		if err != nil {
+			return err
+		}
+
+		// values missing in report are stored as NULL
+		values := []interface{}{lastID}
+		for _, id := range lighthouseCategories {
+			values = append(values, report.categoryScore(id))
+		}
+		for _, id := range lighthouseAudits {
+			values = append(values, report.auditValue(id))
+		}
+
+		_, err = tx.Exec(`
+INSERT INTO lighthouse_statistics (
+	test_id, performance, accessibility, best_practices, seo,
+	largest_contentful_paint, total_blocking_time, cumulative_layout_shift,
+	speed_index, interactive
+) VALUES (
+	?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);`, values...)
+
+		return err
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateParseLighthouseArgs function validates arguments for "parselighthouse" command
+func validateParseLighthouseArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 2 {
+		return errors.New("Please provide two path arguments")
+	}
+
+	// validate if db file is not a dir
+	if fileInf, err := os.Stat(args[0]); err == nil && fileInf.IsDir() {
+		return errors.New("Output file path is invalid")
+	}
+
+	// validate if input file exist and are not a dir
+	if fileInf, err := os.Stat(args[1]); err != nil || fileInf.IsDir() {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parselighthouseCmd represents the parselighthouse command
+var parselighthouseCmd = &cobra.Command{
+	Use:   `parselighthouse path/to/db/file path/to/input/file`,
+	Short: "Parses Lighthouse JSON report into SQLite database",
+	Long: `Parses Lighthouse JSON report from a provided path and populates
+database with category scores and key audits values. Categories and
+audits missing in report, e.g. skipped with "--only-categories", are
+stored as missing rather than zero.`,
+	Args: validateParseLighthouseArgs,
+	Run:  parseLighthouseFiles,
+}
+
+func init() {
+	rootCmd.AddCommand(parselighthouseCmd)
+	addTestMetadataFlags(parselighthouseCmd)
+}
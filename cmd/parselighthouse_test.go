@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodingLighthouseReport(t *testing.T) {
+	// report of a run limited to performance category
+	input := `{
+	"requestedUrl": "https://example.com/",
+	"fetchTime": "2018-10-18T10:00:00.000Z",
+	"categories": {"performance": {"score": 0.954}},
+	"audits": {
+		"largest-contentful-paint": {"numericValue": 1234.5678},
+		"total-blocking-time": {"numericValue": 0},
+		"cumulative-layout-shift": {"numericValue": 0},
+		"interactive": {"score": null}
+	}
+}`
+	report, err := decodeLighthouseReport(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if score := report.categoryScore("performance"); score == nil || *score != 95 {
+		t.Errorf("Expected performance score 95, got %v", score)
+	}
+	if score := report.categoryScore("seo"); score != nil {
+		t.Errorf("Expected missing SEO score, got %v", *score)
+	}
+	if value := report.auditValue("largest-contentful-paint"); value == nil || *value != 1234.568 {
+		t.Errorf("Expected LCP 1234.568, got %v", value)
+	}
+	for _, id := range []string{"total-blocking-time", "cumulative-layout-shift"} {
+		if value := report.auditValue(id); value == nil || *value != 0 {
+			t.Errorf("Expected %s to be zero, got %v", id, value)
+		}
+	}
+	for _, id := range []string{"interactive", "speed-index"} {
+		if value := report.auditValue(id); value != nil {
+			t.Errorf("Expected %s to be missing, got %v", id, *value)
+		}
+	}
+
+	if _, err := decodeLighthouseReport(strings.NewReader(`{"log": {}}`)); err == nil {
+		t.Error("Expected an error for a file which is not a Lighthouse report")
+	}
+}
@@ -19,50 +19,25 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
 
 	"github.com/dakaraj/ptrend/dbutils"
 	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
-	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
 )
 
-// WPTResults struct contains all metric data. Tags are used to match
-// map keys to struct fields
-type WPTResults struct {
-	Responses200             float64 `mapstructure:"responses_200"`
-	BytesOut                 float64 `mapstructure:"bytesOut"`
-	GzipSavings              float64 `mapstructure:"gzip_savings"`
-	RequestsFull             float64 `mapstructure:"requestsFull"`
-	Connections              float64 `mapstructure:"connections"`
-	BytesOutDoc              float64 `mapstructure:"bytesOutDoc"`
-	Result                   float64 `mapstructure:"result"`
-	BasePageSSLTime          float64 `mapstructure:"basePageSSLTime"`
-	DocTime                  float64 `mapstructure:"docTime"`
-	DomContentLoadedEventEnd float64 `mapstructure:"domContentLoadedEventEnd"`
-	ImageSavings             float64 `mapstructure:"image_savings"`
-	RequestsDoc              float64 `mapstructure:"requestsDoc"`
-	FirstTextPaint           float64 `mapstructure:"firstTextPaint"`
-	FirstPaint               float64 `mapstructure:"firstPaint"`
-	ScoreCDN                 float64 `mapstructure:"score_cdn"`
-	CPUIdle                  float64 `mapstructure:"cpu.Idle"`
-	OptimizationChecked      float64 `mapstructure:"optimization_checked"`
-	ImageTotal               float64 `mapstructure:"image_total"`
-	ScoreMinify              float64 `mapstructure:"score_minify"`
-	GzipTotal                float64 `mapstructure:"gzip_total"`
-	Responses404             float64 `mapstructure:"responses_404"`
-	LoadTime                 float64 `mapstructure:"loadTime"`
-	ScoreCombine             float64 `mapstructure:"score_combine"`
-	FirstContentfulPaint     float64 `mapstructure:"firstContentfulPaint"`
-	FirstLayout              float64 `mapstructure:"firstLayout"`
-	ScoreEtags               float64 `mapstructure:"score_etags"`
-}
-
 func validateParseWPTArgs(cmd *cobra.Command, args []string) error {
 	// validate argumets amount
-	if len(args) != 2 {
-		return errors.New("Please provide two path arguments")
+	if len(args) < 2 {
+		return errors.New("Please provide at least two path arguments")
 	}
 
 	// validate if db file is not a dir
@@ -70,143 +45,623 @@ func validateParseWPTArgs(cmd *cobra.Command, args []string) error {
 		return errors.New("Output file path is invalid")
 	}
 
-	// validate if input file exist and are not a dir
-	if fileInf, err := os.Stat(args[1]); err != nil || fileInf.IsDir() {
-		return errors.New("Input file path is invalid or file does not exist")
+	// validate if inputs are existing files, directories or matching patterns
+	files, err := expandWPTInputs(args[1:])
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("No input files found")
+	}
+
+	// validate description template, a static one would give the same
+	// description to every imported result. Fields missing in a result
+	// fail the import instead of being filled in with "<no value>"
+	if wptDescription != "" {
+		tmpl, err := template.New("description").Funcs(wptTemplateFuncs).
+			Option("missingkey=error").Parse(wptDescription)
+		if err != nil {
+			return fmt.Errorf("Provided description template is invalid: %v", err)
+		}
+		if len(files) > 1 && isStaticTemplate(tmpl) {
+			return errors.New("Static description can only be used with a single input file, use a template with result fields, e.g. \"{{.id}} {{.url}}\"")
+		}
+		wptDescriptionTemplate = tmpl
+	}
+
+	// validate test attributes
+	if err := validateTestMetadata(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-// insertWPTMetrics function executes provided statement with values substitution
-func insertWPTMetrics(stmt *sql.Stmt, testID int64, metric string, stats ...interface{}) {
-	stats = append([]interface{}{testID, metric}, stats...)
-	stmt.Exec(stats...)
+var (
+	wptAllowPartial        bool
+	wptDescription         string
+	wptDescriptionTemplate *template.Template
+)
+
+// wptViews maps view names stored in DB to view keys of WPT JSON
+var wptViews = []struct {
+	name string
+	key  string
+}{
+	{"first", "firstView"},
+	{"repeat", "repeatView"},
+}
+
+// wptSummaries maps statistic kinds stored in DB to summary keys of WPT JSON
+var wptSummaries = []struct {
+	name string
+	key  string
+}{
+	{"avg", "average"},
+	{"std", "standardDeviation"},
+	{"med", "median"},
 }
 
-// parseWPTFiles function parses input file storing results into db file
-func parseWPTFiles(cmd *cobra.Command, args []string) {
-	outputPath, inputPath := args[0], args[1]
-	var err error
-	DB, err = sql.Open("sqlite3", outputPath)
-	defer DB.Close()
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+// wptWebVitals contains Core Web Vitals metrics with thresholds of
+// good and poor values. Timing is a name of the metric in "chromeUserTiming"
+// list reported by older WPT agents instead of a flat metric
+var wptWebVitals = []struct {
+	name   string
+	timing string
+	good   float64
+	poor   float64
+}{
+	{"chromeUserTiming.LargestContentfulPaint", "LargestContentfulPaint", 2500, 4000},
+	{"chromeUserTiming.CumulativeLayoutShift", "CumulativeLayoutShift", 0.1, 0.25},
+	{"TotalBlockingTime", "", 200, 600},
+	{"TTFB", "", 800, 1800},
+}
+
+// wptNumber function converts a number decoded from WPT JSON into float
+func wptNumber(value interface{}) (float64, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false
 	}
+	v, err := number.Float64()
 
-	if err := dbutils.Initialize(DB); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+	return v, err == nil
+}
+
+// wptViewMetrics function returns all numeric metrics of a view or a step.
+// Nested objects and arrays are not metrics and are skipped
+func wptViewMetrics(stats map[string]interface{}) map[string]float64 {
+	metrics := make(map[string]float64, len(stats))
+	for name, value := range stats {
+		if v, ok := wptNumber(value); ok {
+			metrics[name] = v
+		}
 	}
 
-	inputFile, err := os.Open(inputPath)
-	defer inputFile.Close()
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+	// extracting Core Web Vitals from user timings list if those
+	// are not reported as flat metrics
+	timings, _ := stats["chromeUserTiming"].([]interface{})
+	for _, vital := range wptWebVitals {
+		if _, ok := metrics[vital.name]; ok || vital.timing == "" {
+			continue
+		}
+		for _, item := range timings {
+			timing, ok := item.(map[string]interface{})
+			if !ok || timing["name"] != vital.timing {
+				continue
+			}
+			value, ok := wptNumber(timing["value"])
+			if !ok {
+				value, ok = wptNumber(timing["time"])
+			}
+			// last entry of a kind contains final value
+			if ok {
+				metrics[vital.name] = value
+			}
+		}
 	}
 
-	// decoding an input JSON file into map
+	return metrics
+}
+
+// wptStep struct contains metrics of a single step of a view
+type wptStep struct {
+	label   string
+	metrics map[string]float64
+}
+
+// wptViewSteps function splits metrics of a multi-step view into steps
+// labelled by event name (or step number if name is not set).
+// Single-step view is returned as one step with an empty label
+func wptViewSteps(viewStats interface{}) []wptStep {
+	stats, ok := viewStats.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	steps, _ := stats["steps"].([]interface{})
+	if len(steps) < 2 {
+		return []wptStep{{"", wptViewMetrics(stats)}}
+	}
+
+	result := make([]wptStep, 0, len(steps))
+	for i, step := range steps {
+		stepStats, ok := step.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		label, _ := stepStats["eventName"].(string)
+		if label == "" {
+			label = fmt.Sprintf("Step %d", i+1)
+		}
+		result = append(result, wptStep{label, wptViewMetrics(stepStats)})
+	}
+
+	return result
+}
+
+// insertWPTMetrics function stores metrics of every step of a view
+// as name/value rows using provided statement
+func insertWPTMetrics(stmt *sql.Stmt, testID int64, statistic, view string, run int, steps []wptStep) error {
+	for _, step := range steps {
+		names := make([]string, 0, len(step.metrics))
+		for name := range step.metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			_, err := stmt.Exec(testID, view, statistic, run, step.label, name, step.metrics[name])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WPTResult struct contains "data" part of WPT JSON result along with
+// problems found while decoding it
+type WPTResult struct {
+	Data         map[string]interface{}
+	ID           string
+	Location     string
+	URL          string
+	Connectivity string
+	Browser      string
+	Completed    time.Time
+	// Problems contains names of missing sections and non-complete status
+	Problems []string
+}
+
+// decodeWPTResult function decodes WPT JSON result and checks that
+// test is complete and all sections used by parser are present.
+// Returns error only if result can not be stored at all
+func decodeWPTResult(input io.Reader) (*WPTResult, error) {
 	var decodedJSON map[string]interface{}
-	decoder := json.NewDecoder(inputFile)
-	decoder.Decode(&decodedJSON)
-	// leaving only "data" part of the JSON in place
-	decodedJSON = decodedJSON["data"].(map[string]interface{})
+	decoder := json.NewDecoder(input)
+	// numbers are kept as is, so they are not reformatted in descriptions
+	decoder.UseNumber()
+	if err := decoder.Decode(&decodedJSON); err != nil {
+		return nil, fmt.Errorf("Failed to decode WPT result: %v", err)
+	}
+
+	result := &WPTResult{}
+	statusCode, hasStatus := wptNumber(decodedJSON["statusCode"])
+	statusText, _ := decodedJSON["statusText"].(string)
+	if hasStatus && statusCode != 200 {
+		result.Problems = append(result.Problems,
+			fmt.Sprintf("test status is %d (%s)", int(statusCode), statusText))
+	}
+
+	data, ok := decodedJSON["data"].(map[string]interface{})
+	if !ok {
+		if hasStatus {
+			return nil, fmt.Errorf("WPT result has no \"data\" section, status is %d (%s)",
+				int(statusCode), statusText)
+		}
+		return nil, errors.New("WPT result has no \"data\" section")
+	}
+	result.Data = data
+
+	if result.ID, ok = data["id"].(string); !ok || result.ID == "" {
+		return nil, errors.New("WPT result has no \"data.id\" field")
+	}
+	if result.Location, ok = data["location"].(string); !ok {
+		result.Problems = append(result.Problems, "missing \"data.location\" field")
+	}
+	result.readAttributes()
+
+	for _, summary := range wptSummaries {
+		summaryData, ok := data[summary.key].(map[string]interface{})
+		if !ok {
+			result.Problems = append(result.Problems,
+				fmt.Sprintf("missing \"data.%s\" section", summary.key))
+			continue
+		}
+		if _, ok := summaryData["firstView"].(map[string]interface{}); !ok {
+			result.Problems = append(result.Problems,
+				fmt.Sprintf("missing \"data.%s.firstView\" section", summary.key))
+		}
+	}
+	if _, ok := data["runs"].(map[string]interface{}); !ok {
+		result.Problems = append(result.Problems, "missing \"data.runs\" section")
+	}
+
+	return result, nil
+}
+
+// expandWPTInputs function turns input arguments into a sorted list of files.
+// Each argument can be a file, a directory with JSON files or a glob pattern
+func expandWPTInputs(args []string) ([]string, error) {
+	unique := map[string]bool{}
+	for _, arg := range args {
+		var matches []string
+		fileInf, err := os.Stat(arg)
+		switch {
+		case err == nil && fileInf.IsDir():
+			matches, _ = filepath.Glob(filepath.Join(arg, "*.json"))
+		case err == nil:
+			matches = []string{arg}
+		default:
+			matches, err = filepath.Glob(arg)
+			if err != nil || len(matches) == 0 {
+				return nil, fmt.Errorf("Input path %q is invalid or does not match any file", arg)
+			}
+		}
+		for _, match := range matches {
+			if fileInf, err := os.Stat(match); err == nil && !fileInf.IsDir() {
+				unique[match] = true
+			}
+		}
+	}
+
+	files := make([]string, 0, len(unique))
+	for file := range unique {
+		files = append(files, file)
+	}
+	sort.Strings(files)
 
-	wptID := decodedJSON["id"].(string)
-	wptLocation := decodedJSON["location"].(string)
+	return files, nil
+}
 
-	// decoding Average stats
-	average := decodedJSON["average"].(map[string]interface{})["firstView"]
-	var avgS WPTResults
-	mapstructure.Decode(average, &avgS)
+// isWPTImported function checks if WPT test with a given id is already stored.
+// Tests imported before WPT ids were stored are matched by description
+func isWPTImported(tx dbExecutor, wptID string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`
+SELECT COUNT(*)
+FROM tests
+WHERE type_id = 2 AND (external_id = ? OR description = ? OR description LIKE ? ESCAPE '\');`,
+		wptID, wptID, escapeLike(wptID)+" (%").Scan(&count)
 
-	// decoding Standard Deviation stats
-	stdDev := decodedJSON["standardDeviation"].(map[string]interface{})["firstView"]
-	var stdS WPTResults
-	mapstructure.Decode(stdDev, &stdS)
+	return count > 0, err
+}
 
-	// decoding Median stats
-	median := decodedJSON["median"].(map[string]interface{})["firstView"]
-	var medS WPTResults
-	mapstructure.Decode(median, &medS)
+// escapeLike function escapes wildcards of LIKE pattern, so the value
+// is only matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// importWPTFile function stores results from a single WPT JSON file.
+// Returns false if test with the same WPT id is already stored
+func importWPTFile(tx dbExecutor, inputPath string) (bool, error) {
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		return false, err
+	}
+	defer inputFile.Close()
+
+	result, err := decodeWPTResult(inputFile)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", inputPath, err)
+	}
+	if imported, err := isWPTImported(tx, result.ID); err != nil || imported {
+		return false, err
+	}
+	if len(result.Problems) > 0 {
+		if !wptAllowPartial {
+			return false, fmt.Errorf("WPT result %s is incomplete: %s\nUse --allow-partial flag to store it anyway",
+				result.ID, strings.Join(result.Problems, ", "))
+		}
+		fmt.Printf("WPT result %s is stored as partial: %s\n",
+			result.ID, strings.Join(result.Problems, ", "))
+	}
+	decodedJSON := result.Data
 
-	description := fmt.Sprintf("%s (%s)", wptID, wptLocation)
+	description, err := result.description(wptDescriptionTemplate)
+	if err != nil {
+		return false, err
+	}
 
 	// inserting new test into db getting row id in return
-	res, err := DB.Exec(`
-INSERT INTO tests (
-	description, type_id
+	lastID, err := insertTestWithMetadata(tx, description, 2, result.period())
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", inputPath, err)
+	}
+	var partial int
+	if len(result.Problems) > 0 {
+		partial = 1
+	}
+	_, err = tx.Exec(`UPDATE tests SET external_id = ?, partial = ? WHERE test_id = ?;`,
+		result.ID, partial, lastID)
+	if err != nil {
+		return false, err
+	}
+
+	var completed interface{}
+	if !result.Completed.IsZero() {
+		completed = result.Completed.Format(dbTimeLayout)
+	}
+	_, err = tx.Exec(`
+INSERT INTO wpt_tests (
+	test_id, url, location, connectivity, browser, completed
 ) VALUES (
-	?, 2
-);`, description)
+	?, ?, ?, ?, ?, ?
+);`, lastID, result.URL, result.Location, result.Connectivity, result.Browser, completed)
 	if err != nil {
-		// stop process if description is not unique
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			fmt.Println("Provided test description is not unique")
-		} else {
-			fmt.Println(err.Error())
-		}
-		os.Exit(1)
+		return false, err
 	}
-	lastID, _ := res.LastInsertId()
 
 	// preparing an insert statement
-	insertStatement, _ := DB.Prepare(`
-INSERT INTO wpt_statistics (
-	test_id, metric, responses_200, bytes_out, gzip_savings, requests_full,
-	connections, bytes_out_doc, result, base_page_ssl_time, doc_time,
-	dom_content_loaded_event_end, image_savings, requests_doc, first_text_paint,
-	first_paint, score_cdn, cpu_idle, optimization_checked, image_total,
-	score_minify, gzip_total, responses_404, load_time, score_combine,
-	first_contentful_paint, first_layout, score_etags
+	insertStatement, err := tx.Prepare(`
+INSERT INTO wpt_metrics (
+	test_id, view, statistic, run, step, name, value
 ) VALUES (
-	?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
-	?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	?, ?, ?, ?, ?, ?, ?
 );`)
+	if err != nil {
+		return false, err
+	}
+	defer insertStatement.Close()
+
+	// inserting Average, Standard Deviation and Median stat metrics
+	// for first (cold cache) and repeat (warm cache) views
+	for _, view := range wptViews {
+		for _, summary := range wptSummaries {
+			summaryData, ok := decodedJSON[summary.key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			viewStats, ok := summaryData[view.key]
+			// repeat view is missing if test was run with "first view only" option
+			if !ok || viewStats == nil {
+				continue
+			}
+			err := insertWPTMetrics(insertStatement, lastID, summary.name, view.name, 0,
+				wptViewSteps(viewStats))
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err := insertWPTRuns(insertStatement, lastID, decodedJSON["runs"]); err != nil {
+		return false, err
+	}
+
+	return true, insertWPTBreakdowns(tx, lastID, decodedJSON["runs"])
+}
+
+// readAttributes function fills in tested URL, connectivity profile,
+// browser and completion time if those are present in result
+func (r *WPTResult) readAttributes() {
+	if r.URL, _ = r.Data["testUrl"].(string); r.URL == "" {
+		r.URL, _ = r.Data["url"].(string)
+	}
+	r.Connectivity, _ = r.Data["connectivity"].(string)
+
+	// browser name is reported by each run, location contains it as well
+	// in "location:browser" form
+	if runs, ok := r.Data["runs"].(map[string]interface{}); ok {
+		if run, ok := runs["1"].(map[string]interface{}); ok {
+			if firstView, ok := run["firstView"].(map[string]interface{}); ok {
+				r.Browser, _ = firstView["browser_name"].(string)
+			}
+		}
+	}
+	if idx := strings.LastIndex(r.Location, ":"); r.Browser == "" && idx >= 0 {
+		r.Browser = r.Location[idx+1:]
+	}
+
+	r.Completed = wptTime(r.Data["completed"])
+}
+
+// period function returns a period WPT test was running in. Test starts
+// with the earliest run and finishes when result is completed
+func (r *WPTResult) period() testPeriod {
+	var period testPeriod
+	runs, _ := r.Data["runs"].(map[string]interface{})
+	for _, runData := range runs {
+		runViews, _ := runData.(map[string]interface{})
+		for _, view := range wptViews {
+			viewStats, _ := runViews[view.key].(map[string]interface{})
+			if date, ok := wptNumber(viewStats["date"]); ok && date > 0 {
+				started := time.Unix(int64(date), 0).UTC()
+				period.observe(started, started)
+			}
+		}
+	}
+	// completion time is known even if runs have no start time
+	if period.started.IsZero() {
+		period.finished = r.Completed
+	} else {
+		period.observe(period.started, r.Completed)
+	}
+
+	return period
+}
+
+// wptTime function converts completion time of WPT test into time.
+// Completion time is either a unix timestamp or a formatted date
+func wptTime(value interface{}) time.Time {
+	if timestamp, ok := wptNumber(value); ok && timestamp > 0 {
+		return time.Unix(int64(timestamp), 0).UTC()
+	}
+	if date, ok := value.(string); ok {
+		if parsed, err := time.Parse(time.RFC1123Z, date); err == nil {
+			return parsed.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+// wptTemplateFuncs contains functions available in description template
+var wptTemplateFuncs = template.FuncMap{
+	// date formats WPT timestamp, e.g. {{date .completed}}
+	"date": func(value interface{}) string {
+		completed := wptTime(value)
+		if completed.IsZero() {
+			return ""
+		}
+		return completed.Format("2006-01-02 15:04")
+	},
+}
+
+// isStaticTemplate function checks if a template contains text only
+func isStaticTemplate(tmpl *template.Template) bool {
+	for _, node := range tmpl.Tree.Root.Nodes {
+		if node.Type() != parse.NodeText {
+			return false
+		}
+	}
 
-	// inserting Average stat metrics
-	insertWPTMetrics(insertStatement, lastID, "avg", avgS.Responses200,
-		avgS.BytesOut, avgS.GzipSavings, avgS.RequestsFull, avgS.Connections,
-		avgS.BytesOutDoc, avgS.Result, avgS.BasePageSSLTime, avgS.DocTime,
-		avgS.DomContentLoadedEventEnd, avgS.ImageSavings, avgS.RequestsDoc,
-		avgS.FirstTextPaint, avgS.FirstPaint, avgS.ScoreCDN, avgS.CPUIdle,
-		avgS.OptimizationChecked, avgS.ImageTotal, avgS.ScoreMinify,
-		avgS.GzipTotal, avgS.Responses404, avgS.LoadTime, avgS.ScoreCombine,
-		avgS.FirstContentfulPaint, avgS.FirstLayout, avgS.ScoreEtags)
-
-	// inserting Standard Deviation stat metrics
-	insertWPTMetrics(insertStatement, lastID, "std", stdS.Responses200,
-		stdS.BytesOut, stdS.GzipSavings, stdS.RequestsFull, stdS.Connections,
-		stdS.BytesOutDoc, stdS.Result, stdS.BasePageSSLTime, stdS.DocTime,
-		stdS.DomContentLoadedEventEnd, stdS.ImageSavings, stdS.RequestsDoc,
-		stdS.FirstTextPaint, stdS.FirstPaint, stdS.ScoreCDN, stdS.CPUIdle,
-		stdS.OptimizationChecked, stdS.ImageTotal, stdS.ScoreMinify,
-		stdS.GzipTotal, stdS.Responses404, stdS.LoadTime, stdS.ScoreCombine,
-		stdS.FirstContentfulPaint, stdS.FirstLayout, stdS.ScoreEtags)
-
-	// inserting Median stat metrics
-	insertWPTMetrics(insertStatement, lastID, "med", medS.Responses200,
-		medS.BytesOut, medS.GzipSavings, medS.RequestsFull, medS.Connections,
-		medS.BytesOutDoc, medS.Result, medS.BasePageSSLTime, medS.DocTime,
-		medS.DomContentLoadedEventEnd, medS.ImageSavings, medS.RequestsDoc,
-		medS.FirstTextPaint, medS.FirstPaint, medS.ScoreCDN, medS.CPUIdle,
-		medS.OptimizationChecked, medS.ImageTotal, medS.ScoreMinify,
-		medS.GzipTotal, medS.Responses404, medS.LoadTime, medS.ScoreCombine,
-		medS.FirstContentfulPaint, medS.FirstLayout, medS.ScoreEtags)
+	return true
+}
+
+// description function builds test description from template filled in with
+// "data" part of WPT JSON. Default is WPT id with location
+func (r *WPTResult) description(tmpl *template.Template) (string, error) {
+	if tmpl == nil {
+		if r.Location == "" {
+			return r.ID, nil
+		}
+		return fmt.Sprintf("%s (%s)", r.ID, r.Location), nil
+	}
+
+	var description strings.Builder
+	if err := tmpl.Execute(&description, r.Data); err != nil {
+		return "", fmt.Errorf("Failed to build description for WPT result %s: %v", r.ID, err)
+	}
+
+	return strings.TrimSpace(description.String()), nil
+}
+
+// parseWPTFiles function parses input files storing results into db file.
+// All files are imported in a single transaction
+func parseWPTFiles(cmd *cobra.Command, args []string) {
+	outputPath := args[0]
+	inputPaths, err := expandWPTInputs(args[1:])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	DB, err = dbutils.Open(outputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	var imported, skipped int
+	err = ingest(DB, func(tx *sql.Tx) error {
+		for _, inputPath := range inputPaths {
+			ok, err := importWPTFile(tx, inputPath)
+			if err != nil {
+				return err
+			}
+			if ok {
+				imported++
+			} else {
+				skipped++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if len(inputPaths) > 1 || skipped > 0 {
+		fmt.Printf("Imported %d WPT results, skipped %d already imported\n", imported, skipped)
+	}
+}
+
+// insertWPTRuns function stores metrics of every individual run of a test,
+// so those can be used for custom statistics later
+func insertWPTRuns(stmt *sql.Stmt, testID int64, runsData interface{}) error {
+	runs, ok := runsData.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	// runs are keyed by their number starting from 1
+	runKeys := make([]string, 0, len(runs))
+	for key := range runs {
+		if _, err := strconv.Atoi(key); err == nil {
+			runKeys = append(runKeys, key)
+		}
+	}
+	sort.Slice(runKeys, func(i, j int) bool {
+		a, _ := strconv.Atoi(runKeys[i])
+		b, _ := strconv.Atoi(runKeys[j])
+		return a < b
+	})
+
+	for _, key := range runKeys {
+		run, _ := strconv.Atoi(key)
+		runViews, ok := runs[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, view := range wptViews {
+			steps := wptViewSteps(runViews[view.key])
+			if steps == nil {
+				continue
+			}
+			if err := insertWPTMetrics(stmt, testID, "run", view.name, run, steps); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 // parsewptCmd represents the parsewpt command
 var parsewptCmd = &cobra.Command{
-	Use:   `parsewpt path/to/db/file path/to/input/file`,
+	Use:   `parsewpt path/to/db/file path/to/input/file [other/input/files/dirs/patterns...]`,
 	Short: "Parses results JSON file into SQLite database",
-	Long: `Parses Web Page Test results file from a provided path
-and populates database with new data.`,
+	Long: `Parses Web Page Test results files from provided paths
+and populates database with new data. Inputs can be files, directories
+with JSON files or glob patterns, all of them are imported in a single
+transaction. Results with already imported WPT ids are skipped.
+Description defaults to WPT id with location and can be customized
+with a template, a static description can only be used for a single
+file. Tested URL, connectivity, browser and completion time are stored
+along with the test and shown by "list", "show", "export" and "generate". Results of multi-step scripts
+are stored per step labelled by event name or step number.
+Results of failed or incomplete tests are refused unless
+"--allow-partial" flag is used.`,
 	Args: validateParseWPTArgs,
 	Run:  parseWPTFiles,
 }
 
 func init() {
 	rootCmd.AddCommand(parsewptCmd)
+
+	parsewptCmd.Flags().StringVar(&wptDescription, "description", "",
+		`Test description or template filled in with "data" part of WPT JSON, e.g. "{{.url}} {{date .completed}} {{.connectivity}}"`)
+	parsewptCmd.Flags().BoolVarP(&wptAllowPartial, "allow-partial", "p", false, "Store failed or incomplete results marking test as partial")
+	addTestMetadataFlags(parsewptCmd)
 }
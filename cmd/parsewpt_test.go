@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestDecodingIncompleteWPTResult(t *testing.T) {
+	input := `{"statusCode": 400, "statusText": "Test not found"}`
+	if _, err := decodeWPTResult(strings.NewReader(input)); err == nil {
+		t.Error("Expected an error for result without data section")
+	}
+
+	input = `{"statusCode": 200, "statusText": "Test Complete", "data": {
+	"id": "181018_AB_1", "location": "Dulles:Chrome",
+	"average": {"firstView": {"loadTime": 1000}},
+	"median": {"firstView": {"loadTime": 900}}
+}}`
+	result, err := decodeWPTResult(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to decode WPT result: %v", err)
+	}
+	expected := []string{
+		`missing "data.standardDeviation" section`,
+		`missing "data.runs" section`,
+	}
+	if strings.Join(result.Problems, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("Expected problems %q, got %q", expected, result.Problems)
+	}
+}
+
+func TestDetectingStaticDescription(t *testing.T) {
+	for description, static := range map[string]bool{
+		"nightly":                  true,
+		"{{.url}} nightly":         false,
+		"{{date .completed}}":      false,
+		"{{/* comment only */}}id": true,
+	} {
+		tmpl, err := template.New("description").Funcs(wptTemplateFuncs).Parse(description)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isStaticTemplate(tmpl) != static {
+			t.Errorf("Expected %q static to be %v", description, static)
+		}
+	}
+}
+
+func TestExtractingWebVitals(t *testing.T) {
+	var stats map[string]interface{}
+	input := `{"TTFB": 300, "TotalBlockingTime": 150, "chromeUserTiming": [
+	{"name": "LargestContentfulPaint", "time": 1800},
+	{"name": "LargestContentfulPaint", "time": 2100},
+	{"name": "CumulativeLayoutShift", "value": 0.12}
+]}`
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := wptViewMetrics(stats)
+	expected := map[string]float64{
+		"TTFB":              300,
+		"TotalBlockingTime": 150,
+		"chromeUserTiming.LargestContentfulPaint": 2100,
+		"chromeUserTiming.CumulativeLayoutShift":  0.12,
+	}
+	for name, value := range expected {
+		if metrics[name] != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, metrics[name])
+		}
+	}
+}
+
+func TestDescribingBatchWithMissingField(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"first.json", "second.json"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wptDescription = "{{.url}} {{.connectivity}}"
+	defer func() { wptDescription, wptDescriptionTemplate = "", nil }()
+	args := []string{filepath.Join(dir, "trends.db"), filepath.Join(dir, "*.json")}
+	if err := validateParseWPTArgs(parsewptCmd, args); err != nil {
+		t.Fatal(err)
+	}
+
+	first := &WPTResult{ID: "181018_AB_1", Data: map[string]interface{}{
+		"url": "https://example.com", "connectivity": "Cable",
+	}}
+	if description, err := first.description(wptDescriptionTemplate); err != nil || description != "https://example.com Cable" {
+		t.Errorf("Unexpected description %q (%v)", description, err)
+	}
+	second := &WPTResult{ID: "181018_AB_2", Data: map[string]interface{}{
+		"url": "https://example.com",
+	}}
+	if description, err := second.description(wptDescriptionTemplate); err == nil {
+		t.Errorf("Expected an error for missing field, got description %q", description)
+	}
+}
+
+func TestMatchingImportedWPTLiterally(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	// stored before WPT ids were kept, so only description is known
+	if _, err := insertTest(DB, "181018_AB_1 (home)", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for wptID, expected := range map[string]bool{
+		"181018_AB_1": true,
+		"181018xABx1": false,
+		"181018%":     false,
+		"181018_AB_%": false,
+	} {
+		imported, err := isWPTImported(DB, wptID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported != expected {
+			t.Errorf("Expected %q to be matched: %t, got: %t", wptID, expected, imported)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//go:build postgres
+// +build postgres
+
+// Tests of PostgreSQL backend need a server, those are built with
+// "postgres" tag, e.g. with a server started by docker:
+//
+//	docker run -d -p 5432:5432 -e POSTGRES_HOST_AUTH_METHOD=trust postgres
+//	PTREND_POSTGRES_DSN=postgres://postgres@localhost/postgres?sslmode=disable go test -tags postgres ./...
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+// TestPostgresStorage runs against a database given by PTREND_POSTGRES_DSN,
+// e.g. postgres://postgres@localhost/ptrend_test?sslmode=disable
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("PTREND_POSTGRES_DSN")
+	if dsn == "" {
+		t.Fatal("PTREND_POSTGRES_DSN is not set")
+	}
+	db, err := dbutils.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i := 0; i < 2; i++ {
+		if err := dbutils.Initialize(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	description := "postgres " + time.Now().Format(time.RFC3339Nano)
+	testID, err := insertTestWithMetadata(db, description, 1, testPeriod{
+		started:  time.Date(2018, 10, 18, 10, 0, 0, 0, time.UTC),
+		finished: time.Date(2018, 10, 18, 10, 1, 30, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Exec(`DELETE FROM tests WHERE test_id = ?;`, testID)
+	if err := insertRequestStats(db, testID, []RequestStats{{Label: "home", Samples: 10, Average: 1.5}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if id, _, err := findTest(db, description); err != nil || id != testID {
+		t.Errorf("Expected test %d to be found, got %d (%v)", testID, id, err)
+	}
+	details := getTestDetailsFromDB(db, []string{description})[0]
+	if details.Started != "2018-10-18 10:00:00" || details.Duration != "1m30s" {
+		t.Errorf("Unexpected test details: %+v", details)
+	}
+	var averages string
+	db.QueryRow(`SELECT `+dbutils.DialectOf(db).GroupConcat("average", ",")+`
+FROM request_statistics WHERE test_id = ?;`, testID).Scan(&averages)
+	if averages != "1.5" {
+		t.Errorf("Expected concatenated averages to be 1.5, got %q", averages)
+	}
+	if _, err := db.Exec(`INSERT INTO tests (description, type_id) VALUES (?, 1);`, description); !dbutils.IsUniqueViolation(err) {
+		t.Errorf("Expected unique violation, got %v", err)
+	}
+
+	// values are read back from PostgreSQL by merge
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target, err := dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	if err := dbutils.Initialize(target); err != nil {
+		t.Fatal(err)
+	}
+	mergeConflict = "skip"
+	if _, _, err := mergeDB(db, target, dsn); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		started string
+		average float64
+	)
+	target.QueryRow(`
+SELECT t.started, rs.average
+FROM tests AS t
+	JOIN request_statistics AS rs ON t.test_id = rs.test_id
+WHERE t.description = ?;`, description).Scan(&started, &average)
+	if started != "2018-10-18 10:00:00" || average != 1.5 {
+		t.Errorf("Expected test to be merged from PostgreSQL, got %q and %v", started, average)
+	}
+}
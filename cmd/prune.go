@@ -0,0 +1,353 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prunePolicyPath string
+	prunePolicy     retentionPolicy
+	pruneDryRun     bool
+)
+
+// protectedTags contains tags of tests that are always kept
+// regardless of retention policy
+var protectedTags = []string{"baseline", "release"}
+
+// retentionPolicy struct contains rules deciding which tests are kept.
+// A test is kept if any of rules keeps it
+type retentionPolicy struct {
+	// KeepLast is an amount of the latest valid tests kept for each test
+	// type, invalid tests are only kept by other rules
+	KeepLast int `json:"keep_last"`
+	// KeepDays is an age in days tests started within are kept
+	KeepDays int `json:"keep_days"`
+	// KeepSince is a date tests started at or after are kept
+	KeepSince string `json:"keep_since"`
+	// KeepTags contains tags in key or key=value form tests are kept by
+	// in addition to protected ones
+	KeepTags []string `json:"keep_tags"`
+	// RawDays is an age in days raw samples of kept tests are dropped after
+	RawDays int `json:"raw_days"`
+}
+
+// pruneTest struct contains a test considered by retention policy
+type pruneTest struct {
+	id          int64
+	description string
+	typeID      int
+	started     string
+	tags        map[string]string
+	hasRaw      bool
+	invalid     bool
+}
+
+// keptByTags function checks if a test has any of tags in key or key=value form
+func (t pruneTest) keptByTags(tags []string) bool {
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, "=", 2)
+		value, ok := t.tags[parts[0]]
+		if ok && (len(parts) == 1 || value == parts[1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadRetentionPolicy function reads policy from a JSON file, values
+// set with flags take precedence over ones from file
+func loadRetentionPolicy(cmd *cobra.Command) (retentionPolicy, error) {
+	var policy retentionPolicy
+	if prunePolicyPath != "" {
+		data, err := ioutil.ReadFile(prunePolicyPath)
+		if err != nil {
+			return policy, err
+		}
+		if err := json.Unmarshal(data, &policy); err != nil {
+			return policy, fmt.Errorf("Retention policy %s is invalid: %v", prunePolicyPath, err)
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("keep-last") {
+		policy.KeepLast = prunePolicy.KeepLast
+	}
+	if flags.Changed("keep-days") {
+		policy.KeepDays = prunePolicy.KeepDays
+	}
+	if flags.Changed("keep-since") {
+		policy.KeepSince = prunePolicy.KeepSince
+	}
+	if flags.Changed("keep-tag") {
+		policy.KeepTags = prunePolicy.KeepTags
+	}
+	if flags.Changed("raw-days") {
+		policy.RawDays = prunePolicy.RawDays
+	}
+
+	if policy.KeepLast < 0 || policy.KeepDays < 0 || policy.RawDays < 0 {
+		return policy, errors.New("Retention policy values should not be negative")
+	}
+	if policy.KeepLast == 0 && policy.KeepDays == 0 && policy.KeepSince == "" {
+		return policy, errors.New("Retention policy should keep tests by amount, age or date")
+	}
+	if policy.KeepSince != "" {
+		if _, err := parseDate(policy.KeepSince); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+// applyRetentionPolicy function returns tests to be deleted and kept
+// tests whose raw samples are to be dropped
+func applyRetentionPolicy(tests []pruneTest, policy retentionPolicy, now time.Time) ([]pruneTest, []pruneTest) {
+	var since, rawBefore string
+	if policy.KeepDays > 0 {
+		since = now.AddDate(0, 0, -policy.KeepDays).UTC().Format(dbTimeLayout)
+	}
+	if policy.KeepSince != "" {
+		date, _ := parseDate(policy.KeepSince)
+		if keepSince := date.Format(dbTimeLayout); since == "" || keepSince < since {
+			since = keepSince
+		}
+	}
+	if policy.RawDays > 0 {
+		rawBefore = now.AddDate(0, 0, -policy.RawDays).UTC().Format(dbTimeLayout)
+	}
+
+	// the latest tests go first, tests without start time are the oldest
+	sorted := append([]pruneTest{}, tests...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].started != sorted[j].started {
+			return sorted[i].started > sorted[j].started
+		}
+		return sorted[i].id > sorted[j].id
+	})
+
+	var deleted, dropRaw []pruneTest
+	perType := make(map[int]int)
+	for _, t := range sorted {
+		latest := false
+		if !t.invalid {
+			perType[t.typeID]++
+			latest = perType[t.typeID] <= policy.KeepLast
+		}
+		kept := latest ||
+			(since != "" && t.started != "" && t.started >= since) ||
+			t.keptByTags(policy.KeepTags) || t.keptByTags(protectedTags)
+		if !kept {
+			deleted = append(deleted, t)
+			continue
+		}
+		if t.hasRaw && rawBefore != "" && t.started < rawBefore {
+			dropRaw = append(dropRaw, t)
+		}
+	}
+
+	return deleted, dropRaw
+}
+
+// getPruneTestsFromDB function returns all tests with their start time,
+// tags and presence of raw samples
+func getPruneTestsFromDB(DB *sql.DB) []pruneTest {
+	rows, err := DB.Query(`
+SELECT t.test_id, t.description, t.type_id,
+	COALESCE(` + dbutils.DialectOf(DB).FormatTime("COALESCE(t.started, t.finished)") + `, ''),
+	(SELECT COUNT(*) FROM raw_samples WHERE test_id = t.test_id), t.invalid
+FROM tests AS t
+ORDER BY t.test_id ASC;`)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	var tests []pruneTest
+	index := make(map[int64]int)
+	for rows.Next() {
+		var (
+			t   pruneTest
+			raw int
+		)
+		if err := rows.Scan(&t.id, &t.description, &t.typeID, &t.started, &raw, &t.invalid); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		t.hasRaw = raw > 0
+		t.tags = make(map[string]string)
+		index[t.id] = len(tests)
+		tests = append(tests, t)
+	}
+	rows.Close()
+
+	rows, err = DB.Query(`SELECT test_id, key, value FROM test_tags;`)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id         int64
+			key, value string
+		)
+		rows.Scan(&id, &key, &value)
+		if i, ok := index[id]; ok {
+			tests[i].tags[key] = value
+		}
+	}
+
+	return tests
+}
+
+// pruneTests function deletes tests not kept by retention policy
+// and drops raw samples of old tests
+func pruneTests(cmd *cobra.Command, args []string) {
+	inputPath := args[0]
+	policy, err := loadRetentionPolicy(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	deleted, dropRaw := applyRetentionPolicy(getPruneTestsFromDB(DB), policy, time.Now())
+	if pruneDryRun {
+		for _, t := range deleted {
+			fmt.Printf("Would delete test %d: %s\n", t.id, t.description)
+		}
+		for _, t := range dropRaw {
+			fmt.Printf("Would drop raw samples of test %d: %s\n", t.id, t.description)
+		}
+		fmt.Printf("Would delete %d tests and drop raw samples of %d tests\n", len(deleted), len(dropRaw))
+		return
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer tx.Rollback()
+
+	// statistics are removed by foreign key cascade
+	for _, t := range deleted {
+		if _, err := tx.Exec(`DELETE FROM tests WHERE test_id = ?;`, t.id); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted test %d: %s\n", t.id, t.description)
+	}
+	for _, t := range dropRaw {
+		if _, err := tx.Exec(`DELETE FROM raw_samples WHERE test_id = ?;`, t.id); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Dropped raw samples of test %d: %s\n", t.id, t.description)
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// SQLite file does not shrink unless it is rebuilt
+	if (len(deleted) > 0 || len(dropRaw) > 0) && !dbutils.IsDSN(inputPath) {
+		if _, err := DB.Exec(`VACUUM;`); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Deleted %d tests and dropped raw samples of %d tests\n", len(deleted), len(dropRaw))
+}
+
+// validatePruneArgs function validates arguments for "prune" command
+func validatePruneArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 1 {
+		return errors.New("Please provide path to DB file as a single argument")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate if policy file exists and is not a dir
+	if prunePolicyPath != "" {
+		if fileInf, err := os.Stat(prunePolicyPath); err != nil || fileInf.IsDir() {
+			return errors.New("Policy file path is invalid or file does not exist")
+		}
+	}
+
+	return nil
+}
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune path/to/db/file",
+	Short: "Delete old tests according to a retention policy",
+	Long: `Delete tests not kept by a retention policy along with all of
+their statistics. A test is kept if it is one of the latest valid tests
+of its type, started within a number of days or after a date, or has one
+of the kept tags. Invalid tests do not count towards the latest ones.
+Tests tagged with "baseline" or "release" key are always kept. Raw samples
+of kept tests are dropped once those are older than a number of days, statistics
+stay intact.
+
+Policy can be read from a JSON file, e.g.
+{"keep_last": 30, "keep_days": 90, "keep_tags": ["pinned", "team=core"], "raw_days": 14}
+and overridden by flags. Use "dry-run" flag to list what would be removed.`,
+	Args: validatePruneArgs,
+	Run:  pruneTests,
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().StringVarP(&prunePolicyPath, "policy", "p", "", "Path to JSON file with retention policy")
+	pruneCmd.Flags().IntVar(&prunePolicy.KeepLast, "keep-last", 0, "Amount of the latest valid tests kept for each test type")
+	pruneCmd.Flags().IntVar(&prunePolicy.KeepDays, "keep-days", 0, "Keep tests started within a number of days")
+	pruneCmd.Flags().StringVar(&prunePolicy.KeepSince, "keep-since", "", "Keep tests started at or after a date (YYYY-MM-DD[ HH:MM:SS])")
+	pruneCmd.Flags().StringArrayVar(&prunePolicy.KeepTags, "keep-tag", nil, "Keep tests having a tag in key or key=value form in addition to baseline and release, can be repeated")
+	pruneCmd.Flags().IntVar(&prunePolicy.RawDays, "raw-days", 0, "Drop raw samples of kept tests older than a number of days")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only list tests and raw samples that would be removed")
+}
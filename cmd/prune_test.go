@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyingRetentionPolicy(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	tests := []pruneTest{
+		{id: 1, description: "legacy", typeID: 1},
+		{id: 2, description: "release", typeID: 1, started: "2024-01-01 00:00:00",
+			tags: map[string]string{"release": "1.0"}},
+		{id: 3, description: "old", typeID: 1, started: "2024-02-01 00:00:00", hasRaw: true},
+		{id: 4, description: "recent", typeID: 1, started: "2024-06-20 00:00:00", hasRaw: true},
+		{id: 5, description: "latest", typeID: 1, started: "2024-06-29 00:00:00", hasRaw: true},
+		{id: 6, description: "page", typeID: 2, started: "2024-01-01 00:00:00"},
+		{id: 7, description: "pinned", typeID: 2, started: "2023-12-01 00:00:00",
+			tags: map[string]string{"team": "core"}},
+		{id: 8, description: "baseline", typeID: 2, started: "2023-11-01 00:00:00",
+			tags: map[string]string{"baseline": ""}},
+	}
+	// the latest page test is invalid, so it does not count towards keep_last
+	withInvalid := append(append([]pruneTest{}, tests...),
+		pruneTest{id: 9, description: "broken", typeID: 2, started: "2024-03-01 00:00:00", invalid: true})
+
+	for _, c := range []struct {
+		name    string
+		tests   []pruneTest
+		deleted string
+		dropRaw string
+	}{
+		{"valid tests", tests, "old,legacy", "recent"},
+		{"invalid latest test", withInvalid, "broken,old,legacy", "recent"},
+	} {
+		deleted, dropRaw := applyRetentionPolicy(c.tests, retentionPolicy{
+			KeepLast: 1,
+			KeepDays: 30,
+			KeepTags: []string{"team=core"},
+			RawDays:  7,
+		}, now)
+
+		var names []string
+		for _, test := range deleted {
+			names = append(names, test.description)
+		}
+		if strings.Join(names, ",") != c.deleted {
+			t.Errorf("%s: expected %s tests to be deleted, got %v", c.name, c.deleted, names)
+		}
+		names = nil
+		for _, test := range dropRaw {
+			names = append(names, test.description)
+		}
+		if strings.Join(names, ",") != c.dropRaw {
+			t.Errorf("%s: expected raw samples of %s tests to be dropped, got %v", c.name, c.dropRaw, names)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// keepRaw makes parser store every sample of a log along with statistics
+	keepRaw bool
+	// rawRecords contains samples collected when "keep-raw" flag is set
+	rawRecords []rawSample
+)
+
+// rawSampleHeader contains names of fields of stored samples
+var rawSampleHeader = []string{"timestamp", "label", "elapsed", "success", "code"}
+
+// rawSample struct contains a single sample of a log
+type rawSample struct {
+	// Timestamp is a start time in milliseconds or 0 if it is unknown
+	Timestamp int64
+	Label     string
+	Elapsed   int
+	Success   bool
+	Code      string
+}
+
+// newRawSample function creates a sample from a split line of Jmeter log.
+// Response code and success are taken from default columns of CSV log,
+// sample is considered successful if log has no such column
+func newRawSample(record []string) rawSample {
+	sample := rawSample{Label: record[2], Success: true}
+	sample.Timestamp, _ = strconv.ParseInt(record[0], 10, 64)
+	sample.Elapsed, _ = strconv.Atoi(record[1])
+	if len(record) > 3 {
+		sample.Code = record[3]
+	}
+	if len(record) > 7 {
+		sample.Success = strings.EqualFold(record[7], "true")
+	}
+
+	return sample
+}
+
+// encodeRawSamples function packs samples into gzipped CSV
+func encodeRawSamples(samples []rawSample) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	writer := csv.NewWriter(zw)
+	writer.Write(rawSampleHeader)
+	for _, s := range samples {
+		writer.Write([]string{
+			strconv.FormatInt(s.Timestamp, 10), s.Label, strconv.Itoa(s.Elapsed),
+			strconv.FormatBool(s.Success), s.Code,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeRawSamples function unpacks samples packed by encodeRawSamples
+func decodeRawSamples(data []byte) ([]rawSample, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	reader := csv.NewReader(zr)
+	reader.FieldsPerRecord = len(rawSampleHeader)
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+	var samples []rawSample
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var s rawSample
+		s.Label, s.Code = record[1], record[4]
+		if s.Timestamp, err = strconv.ParseInt(record[0], 10, 64); err != nil {
+			return nil, err
+		}
+		if s.Elapsed, err = strconv.Atoi(record[2]); err != nil {
+			return nil, err
+		}
+		if s.Success, err = strconv.ParseBool(record[3]); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+}
+
+// insertRawSamples function stores all samples of a test in DB
+func insertRawSamples(DB dbExecutor, testID int64, samples []rawSample) error {
+	data, err := encodeRawSamples(samples)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`INSERT INTO raw_samples (test_id, samples, data) VALUES (?, ?, ?);`,
+		testID, len(samples), data)
+
+	return err
+}
+
+// getRawSamplesFromDB function returns stored samples of a test
+func getRawSamplesFromDB(DB dbExecutor, testID int64) ([]rawSample, error) {
+	var data []byte
+	err := DB.QueryRow(`SELECT data FROM raw_samples WHERE test_id = ?;`, testID).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRawSamples(data)
+}
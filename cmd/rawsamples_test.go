@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestEncodingRawSamples(t *testing.T) {
+	samples := []rawSample{
+		newRawSample([]string{"1539856800000", "120", "login, step 1", "200", "OK", "Thread 1-1", "text", "true"}),
+		newRawSample([]string{"1539856801000", "3000", "search", "500", "Error", "Thread 1-2", "text", "false"}),
+		newRawSample([]string{"1539856802000", "80", "logout"}),
+	}
+	data, err := encodeRawSamples(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeRawSamples(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(samples) {
+		t.Fatalf("Expected %d samples, got %d", len(samples), len(decoded))
+	}
+	for i := range samples {
+		if decoded[i] != samples[i] {
+			t.Errorf("Expected %+v, got %+v", samples[i], decoded[i])
+		}
+	}
+	if decoded[1].Success || decoded[1].Code != "500" || !decoded[2].Success {
+		t.Errorf("Unexpected success or code: %+v", decoded)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recomputeIgnore      string
+	recomputeInclude     string
+	recomputeNormalize   []string
+	recomputeTrimStart   time.Duration
+	recomputeTrimEnd     time.Duration
+	recomputePercentiles []int
+	recomputeForce       bool
+)
+
+// labelRule struct contains a label normalization rule
+type labelRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// recomputeSettings struct contains rules statistics are recomputed with
+type recomputeSettings struct {
+	ignore      *regexp.Regexp
+	include     *regexp.Regexp
+	rules       []labelRule
+	trimStart   time.Duration
+	trimEnd     time.Duration
+	percentiles []int
+	// force allows clearing statistics if no samples are left
+	force bool
+}
+
+// parseLabelRules function parses "regex=replacement" normalization rules
+func parseLabelRules(list []string) ([]labelRule, error) {
+	rules := make([]labelRule, 0, len(list))
+	for _, rule := range list {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Normalization rule %q should be in regex=replacement form", rule)
+		}
+		pattern, err := regexp.Compile(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("Normalization rule %q has invalid pattern: %v", rule, err)
+		}
+		rules = append(rules, labelRule{pattern, parts[1]})
+	}
+
+	return rules, nil
+}
+
+// recomputeStats function calculates per-label statistics of samples
+// along with extra percentiles. Samples are trimmed first, then labels
+// are normalized and matched by include and ignore patterns
+func recomputeStats(samples []rawSample, s recomputeSettings) ([]RequestStats, map[string]map[int]float64, error) {
+	if (s.trimStart > 0 || s.trimEnd > 0) && len(samples) > 0 {
+		var first, last int64
+		for i, sample := range samples {
+			if sample.Timestamp == 0 {
+				return nil, nil, errors.New("Samples have no timestamps, so those can not be trimmed")
+			}
+			end := sample.Timestamp + int64(sample.Elapsed)
+			if i == 0 || sample.Timestamp < first {
+				first = sample.Timestamp
+			}
+			if end > last {
+				last = end
+			}
+		}
+		from := first + s.trimStart.Nanoseconds()/int64(time.Millisecond)
+		to := last - s.trimEnd.Nanoseconds()/int64(time.Millisecond)
+		trimmed := make([]rawSample, 0, len(samples))
+		for _, sample := range samples {
+			if sample.Timestamp >= from && sample.Timestamp+int64(sample.Elapsed) <= to {
+				trimmed = append(trimmed, sample)
+			}
+		}
+		samples = trimmed
+	}
+
+	byLabel := make(map[string][]int)
+	for _, sample := range samples {
+		label := sample.Label
+		for _, rule := range s.rules {
+			label = rule.pattern.ReplaceAllString(label, rule.replacement)
+		}
+		if s.include != nil && !s.include.MatchString(label) {
+			continue
+		}
+		if s.ignore != nil && s.ignore.MatchString(label) {
+			continue
+		}
+		byLabel[label] = append(byLabel[label], sample.Elapsed)
+	}
+
+	labels := make([]string, 0, len(byLabel))
+	for label := range byLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	stats := make([]RequestStats, 0, len(labels))
+	percentiles := make(map[string]map[int]float64, len(labels))
+	for _, label := range labels {
+		elapsed := byLabel[label]
+		rs := RequestStats{Label: label, Samples: len(elapsed)}
+		calculateStats(elapsed, &rs)
+		stats = append(stats, rs)
+		if len(s.percentiles) > 0 {
+			percentiles[label] = make(map[int]float64, len(s.percentiles))
+			for _, perc := range s.percentiles {
+				percentiles[label][perc] = calculatePercentile(elapsed, perc)
+			}
+		}
+	}
+
+	return stats, percentiles, nil
+}
+
+// replaceRequestStats function replaces per-label statistics and extra
+// percentiles of a test
+func replaceRequestStats(DB dbExecutor, testID int64, stats []RequestStats, percentiles map[string]map[int]float64) error {
+	for _, table := range []string{"request_statistics", "request_percentiles"} {
+		if _, err := DB.Exec(fmt.Sprintf(`DELETE FROM %s WHERE test_id = ?;`, table), testID); err != nil {
+			return err
+		}
+	}
+	if err := insertRequestStats(DB, testID, stats); err != nil {
+		return err
+	}
+
+	insertStatement, err := DB.Prepare(`
+INSERT INTO request_percentiles (
+	test_id, label, percentile, value
+) VALUES (
+	?, ?, ?, ?
+);`)
+	if err != nil {
+		return err
+	}
+	defer insertStatement.Close()
+
+	for label, values := range percentiles {
+		for perc, value := range values {
+			if _, err := insertStatement.Exec(testID, label, perc, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// recomputeTest function replaces statistics of a test with ones
+// recomputed from its raw samples. Statistics are only cleared
+// by recomputing if forced
+func recomputeTest(DB dbExecutor, testID int64, samples []rawSample, s recomputeSettings) ([]RequestStats, error) {
+	stats, percentiles, err := recomputeStats(samples, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 && !s.force {
+		return nil, errors.New("No samples left after trimming and filtering, use --force flag to clear statistics anyway")
+	}
+	if err := replaceRequestStats(DB, testID, stats, percentiles); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// recomputeTests function regenerates per-label statistics of tests
+// from their raw samples
+func recomputeTests(cmd *cobra.Command, args []string) {
+	inputPath, tests := args[0], args[1:]
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	settings := recomputeSettings{
+		trimStart:   recomputeTrimStart,
+		trimEnd:     recomputeTrimEnd,
+		percentiles: recomputePercentiles,
+		force:       recomputeForce,
+	}
+	if recomputeIgnore != "" {
+		settings.ignore = regexp.MustCompile(recomputeIgnore)
+	}
+	if recomputeInclude != "" {
+		settings.include = regexp.MustCompile(recomputeInclude)
+	}
+	settings.rules, _ = parseLabelRules(recomputeNormalize)
+
+	// all load tests matched by filter flags are recomputed
+	// if no tests are given explicitly
+	explicit := len(tests) > 0
+	if !explicit {
+		tests = getTestsFromDB(DB, 1)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer tx.Rollback()
+
+	var recomputed int
+	for _, test := range tests {
+		testID, description, err := findTest(tx, test)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		samples, err := getRawSamplesFromDB(tx, testID)
+		if err == sql.ErrNoRows {
+			if explicit {
+				fmt.Printf("Test %q has no raw samples, parse its log with \"keep-raw\" flag\n", description)
+				os.Exit(1)
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		stats, err := recomputeTest(tx, testID, samples, settings)
+		if err != nil {
+			fmt.Printf("Test %q: %s\n", description, err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Recomputed test %d: %s (%d labels)\n", testID, description, len(stats))
+		recomputed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if recomputed == 0 {
+		fmt.Println("No tests with raw samples found")
+	}
+}
+
+// validateRecomputeArgs function validates arguments for "recompute" command
+func validateRecomputeArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) < 1 {
+		return errors.New("Please provide path to DB file and optionally test ids or descriptions")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate provided patterns and rules
+	for _, pattern := range []string{recomputeIgnore, recomputeInclude} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("Provided pattern %q is invalid", pattern)
+		}
+	}
+	if _, err := parseLabelRules(recomputeNormalize); err != nil {
+		return err
+	}
+
+	// validate trimming and percentiles
+	if recomputeTrimStart < 0 || recomputeTrimEnd < 0 {
+		return errors.New("Trimmed duration should not be negative")
+	}
+	for _, perc := range recomputePercentiles {
+		if perc <= 0 || perc >= 100 {
+			return fmt.Errorf("Percentile %d should be between 0 and 100", perc)
+		}
+	}
+
+	// validate filter flags
+	return validateTestFilter()
+}
+
+// recomputeCmd represents the recompute command
+var recomputeCmd = &cobra.Command{
+	Use:   `recompute path/to/db/file ["test id or description"...]`,
+	Short: "Recompute statistics of tests from their raw samples",
+	Long: `Regenerate per-label statistics of tests parsed with "keep-raw"
+flag, so trend history stays consistent when reporting conventions change.
+If no tests are given, all load tests matched by filter flags having raw
+samples are recomputed.
+
+Samples are trimmed by ramp-up and ramp-down durations first. Then labels
+are normalized by rules applied in order, e.g. "/users/\d+=/users/{id}",
+and matched by include and ignore patterns. Extra percentiles are stored
+along with statistics, shown by "show" command and exported by "export"
+command with metric "percNN", e.g. "perc75". Tests having no samples
+left after trimming and filtering keep their statistics unless
+"force" flag is set.`,
+	Args: validateRecomputeArgs,
+	Run:  recomputeTests,
+}
+
+func init() {
+	rootCmd.AddCommand(recomputeCmd)
+
+	recomputeCmd.Flags().StringVarP(&recomputeIgnore, "ignore-pattern", "i", "", "Label regex pattern that will be ignored")
+	recomputeCmd.Flags().StringVar(&recomputeInclude, "include-pattern", "", "Label regex pattern that will only be included")
+	recomputeCmd.Flags().StringArrayVar(&recomputeNormalize, "normalize", nil, "Label normalization rule in regex=replacement form, can be repeated")
+	recomputeCmd.Flags().DurationVar(&recomputeTrimStart, "trim-start", 0, "Ramp-up duration to drop from the start of a test, e.g. 5m")
+	recomputeCmd.Flags().DurationVar(&recomputeTrimEnd, "trim-end", 0, "Ramp-down duration to drop from the end of a test")
+	recomputeCmd.Flags().IntSliceVar(&recomputePercentiles, "percentiles", nil, "Extra percentiles to store, e.g. 75,99")
+	recomputeCmd.Flags().BoolVar(&recomputeForce, "force", false, "Clear statistics of tests having no samples left after trimming and filtering")
+	addTestFilterFlags(recomputeCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestRecomputingStats(t *testing.T) {
+	samples := []rawSample{
+		{Timestamp: 1000, Label: "/users/1", Elapsed: 100, Success: true},
+		{Timestamp: 5000, Label: "/users/2", Elapsed: 200, Success: true},
+		{Timestamp: 6000, Label: "/users/3", Elapsed: 300, Success: true},
+		{Timestamp: 7000, Label: "/static/app.js", Elapsed: 10, Success: true},
+		{Timestamp: 9000, Label: "/users/4", Elapsed: 400, Success: true},
+	}
+	rules, err := parseLabelRules([]string{`/users/\d+=/users/{id}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, percentiles, err := recomputeStats(samples, recomputeSettings{
+		ignore:      regexp.MustCompile(`^/static/`),
+		rules:       rules,
+		trimStart:   2 * time.Second,
+		trimEnd:     time.Second,
+		percentiles: []int{75},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Label != "/users/{id}" {
+		t.Fatalf("Unexpected labels: %+v", stats)
+	}
+	if stats[0].Samples != 2 || stats[0].Min != 200 || stats[0].Max != 300 {
+		t.Errorf("Expected trimmed samples to be dropped, got %+v", stats[0])
+	}
+	if percentiles["/users/{id}"][75] != 275 {
+		t.Errorf("Expected 75th percentile to be 275, got %v", percentiles["/users/{id}"][75])
+	}
+
+	samples[0].Timestamp = 0
+	if _, _, err := recomputeStats(samples, recomputeSettings{trimStart: time.Second}); err == nil {
+		t.Error("Expected an error for trimming samples without timestamps")
+	}
+}
+
+func TestRecomputingWithoutSamplesLeft(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	testID, err := insertTest(DB, "jmeter", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := insertRequestStats(DB, testID, []RequestStats{{Label: "/static/app.js", Samples: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	countStats := func() int {
+		var count int
+		if err := DB.QueryRow(`SELECT COUNT(*) FROM request_statistics WHERE test_id = ?;`, testID).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+	samples := []rawSample{{Label: "/static/app.js", Elapsed: 10, Success: true}}
+	settings := recomputeSettings{ignore: regexp.MustCompile(`^/static/`)}
+	if _, err := recomputeTest(DB, testID, samples, settings); err == nil {
+		t.Error("Expected an error for recomputing test without samples left")
+	}
+	if count := countStats(); count != 1 {
+		t.Errorf("Expected statistics to be kept, got %d rows", count)
+	}
+
+	settings.force = true
+	if _, err := recomputeTest(DB, testID, samples, settings); err != nil {
+		t.Fatal(err)
+	}
+	if count := countStats(); count != 0 {
+		t.Errorf("Expected forced recompute to clear statistics, got %d rows", count)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	"github.com/spf13/cobra"
+)
+
+// renameTest function changes description of a test
+func renameTest(cmd *cobra.Command, args []string) {
+	inputPath, test := args[0], args[1]
+	// removing all commas as those are used for concatenation later
+	description := strings.Replace(args[2], ",", "", -1)
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	testID, oldDescription, err := findTest(DB, test)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	_, err = DB.Exec(`UPDATE tests SET description = ? WHERE test_id = ?;`, description, testID)
+	if err != nil {
+		// stop process if description is not unique
+		if dbutils.IsUniqueViolation(err) {
+			fmt.Println("Provided test description is not unique")
+		} else {
+			fmt.Println(err.Error())
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("Renamed test %d: %s -> %s\n", testID, oldDescription, description)
+}
+
+// validateRenameArgs function validates arguments for "rename" command
+func validateRenameArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 3 {
+		return errors.New("Please provide path to DB file, test id or description and a new description")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate new description is not empty
+	if strings.TrimSpace(strings.Replace(args[2], ",", "", -1)) == "" {
+		return errors.New("New test description should not be empty")
+	}
+
+	return nil
+}
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   `rename path/to/db/file "test id or description" "new unique description"`,
+	Short: "Change description of a test",
+	Long: `Change description of a test identified by id or description.
+New description should be unique, commas are removed from it.`,
+	Args: validateRenameArgs,
+	Run:  renameTest,
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
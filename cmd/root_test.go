@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestIngestingAtomically(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := dbutils.Initialize(db); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ingest(db, func(tx *sql.Tx) error {
+		testID, err := insertTestWithMetadata(tx, "nightly", 1, testPeriod{})
+		if err != nil {
+			return err
+		}
+		return insertRequestStats(tx, testID, []RequestStats{{Label: "home"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ingest(db, func(tx *sql.Tx) error {
+		if _, err := insertTest(tx, "broken", 1); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT INTO request_statistics (test_id) VALUES (0);`)
+		return err
+	})
+	if err == nil {
+		t.Fatal("Expected an error for invalid statistics")
+	}
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM tests;`).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected failed ingest to leave 1 test, got %d", count)
+	}
+
+	err = ingest(db, func(tx *sql.Tx) error {
+		_, err := insertTest(tx, "nightly", 1)
+		return err
+	})
+	if err == nil || err.Error() != "Provided test description is not unique" {
+		t.Errorf("Expected an error for duplicate description, got %v", err)
+	}
+}
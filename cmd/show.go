@@ -0,0 +1,412 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dakaraj/ptrend/dbutils"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+	"github.com/spf13/cobra"
+)
+
+var (
+	// showSortList contains valid values for "sort" flag
+	showSortList = append([]string{"label", "samples"}, metrics...)
+	showSort     string
+	showDesc     bool
+)
+
+// showTest function prints attributes of a test along with its
+// statistics depending on test type
+func showTest(cmd *cobra.Command, args []string) {
+	inputPath, test := args[0], args[1]
+	var err error
+	DB, err = dbutils.Open(inputPath)
+	defer DB.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// upgrading schema of DB created by earlier builds
+	if err := dbutils.Initialize(DB); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	testID, description, err := findTest(DB, test)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	var typeID int
+	if err := DB.QueryRow(`SELECT type_id FROM tests WHERE test_id = ?;`, testID).Scan(&typeID); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	details := getTestDetailsFromDB(DB, []string{description})[0]
+	fmt.Printf("Test %d: %s\n", testID, description)
+	if summary := details.summary(); summary != "" {
+		fmt.Println(strings.Replace(summary, "; ", "\n", -1))
+	}
+	fmt.Println()
+
+	switch typeID {
+	case 2:
+		showWPTMetrics(os.Stdout, testID)
+	case 3:
+		showLighthouseStatistics(os.Stdout, testID)
+	case 4:
+		// total times of HAR requests are kept as request statistics
+		showHARPages(os.Stdout, testID)
+		fmt.Println()
+		showRequestStatistics(os.Stdout, testID)
+	default:
+		showRequestStatistics(os.Stdout, testID)
+	}
+}
+
+// showRequestStatistics function prints per-label statistics of a test
+// sorted by "sort" flag
+func showRequestStatistics(out io.Writer, testID int64) {
+	order := "ASC"
+	if showDesc {
+		order = "DESC"
+	}
+	rows, err := DB.Query(fmt.Sprintf(`
+SELECT label, samples, average, median, perc90, perc95, perc99, min, max
+FROM request_statistics
+WHERE test_id = ?
+ORDER BY %s %s, label ASC;`, showSort, order), testID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	percentiles, extra := getRequestPercentilesFromDB(DB, testID)
+	header := "Label\tSamples\tAverage\tMedian\t90%\t95%\t99%\tMin\tMax"
+	for _, perc := range percentiles {
+		header += fmt.Sprintf("\t%d%%", perc)
+	}
+	// response codes are only known for some test types, e.g. Artillery
+	codes := getResponseCodesFromDB(DB, testID)
+	if len(codes) > 0 {
+		header += "\tCodes"
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, header)
+	var count int
+	for rows.Next() {
+		var rs RequestStats
+		err := rows.Scan(&rs.Label, &rs.Samples, &rs.Average, &rs.Median,
+			&rs.Perc90, &rs.Perc95, &rs.Perc99, &rs.Min, &rs.Max)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fields := []string{
+			rs.Label, strconv.Itoa(rs.Samples),
+			formatStat(rs.Average), formatStat(rs.Median), formatStat(rs.Perc90),
+			formatStat(rs.Perc95), formatStat(rs.Perc99),
+			strconv.Itoa(rs.Min), strconv.Itoa(rs.Max),
+		}
+		for _, perc := range percentiles {
+			value, ok := extra[rs.Label][perc]
+			if !ok {
+				fields = append(fields, "-")
+				continue
+			}
+			fields = append(fields, formatStat(value))
+		}
+		if len(codes) > 0 {
+			fields = append(fields, codes[rs.Label])
+		}
+		fmt.Fprintln(writer, strings.Join(fields, "\t"))
+		count++
+	}
+	if count == 0 {
+		fmt.Fprintln(out, "Test has no per-label statistics")
+		return
+	}
+	writer.Flush()
+}
+
+// showWPTMetrics function prints average, median and standard deviation
+// of WPT metrics per view and step. Metrics of individual runs are
+// left for "export" command
+func showWPTMetrics(out io.Writer, testID int64) {
+	rows, err := DB.Query(`
+SELECT view, step, name, statistic, value
+FROM wpt_metrics
+WHERE test_id = ? AND statistic IN ('avg', 'med', 'std')
+ORDER BY wpt_metric_id ASC;`, testID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	// metrics are kept in order of import, which is view, step and name
+	type metricKey struct{ view, step, name string }
+	values := map[metricKey]map[string]float64{}
+	var (
+		keys     []metricKey
+		hasSteps bool
+	)
+	for rows.Next() {
+		var (
+			key       metricKey
+			statistic string
+			value     float64
+		)
+		if err := rows.Scan(&key.view, &key.step, &key.name, &statistic, &value); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		if _, ok := values[key]; !ok {
+			values[key] = map[string]float64{}
+			keys = append(keys, key)
+		}
+		values[key][statistic] = value
+		hasSteps = hasSteps || key.step != ""
+	}
+	if len(keys) == 0 {
+		fmt.Fprintln(out, "Test has no WPT metrics")
+		return
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := "View\tMetric\tAverage\tMedian\tStd"
+	if hasSteps {
+		header = "View\tStep\tMetric\tAverage\tMedian\tStd"
+	}
+	fmt.Fprintln(writer, header)
+	for _, key := range keys {
+		fields := []string{key.view}
+		if hasSteps {
+			fields = append(fields, key.step)
+		}
+		fields = append(fields, key.name)
+		for _, statistic := range []string{"avg", "med", "std"} {
+			value, ok := values[key][statistic]
+			if !ok {
+				fields = append(fields, "-")
+				continue
+			}
+			fields = append(fields, formatStat(value))
+		}
+		fmt.Fprintln(writer, strings.Join(fields, "\t"))
+	}
+	writer.Flush()
+}
+
+// showLighthouseStatistics function prints Lighthouse category scores
+// and audit values of a test
+func showLighthouseStatistics(out io.Writer, testID int64) {
+	ids := append(append([]string{}, lighthouseCategories...), lighthouseAudits...)
+	values := make([]sql.NullFloat64, len(ids))
+	scanArgs := make([]interface{}, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	err := DB.QueryRow(`
+SELECT performance, accessibility, best_practices, seo,
+	largest_contentful_paint, total_blocking_time, cumulative_layout_shift,
+	speed_index, interactive
+FROM lighthouse_statistics
+WHERE test_id = ?;`, testID).Scan(scanArgs...)
+	if err == sql.ErrNoRows {
+		fmt.Fprintln(out, "Test has no Lighthouse audits")
+		return
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Audit\tValue")
+	for i, id := range ids {
+		value := "-"
+		if values[i].Valid {
+			value = formatStat(values[i].Float64)
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", id, value)
+	}
+	writer.Flush()
+}
+
+// showHARPages function prints timings of pages of a HAR test
+func showHARPages(out io.Writer, testID int64) {
+	rows, err := DB.Query(`
+SELECT page, on_content_load, on_load
+FROM har_pages
+WHERE test_id = ?
+ORDER BY page_id ASC;`, testID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Page\tonContentLoad\tonLoad")
+	var count int
+	for rows.Next() {
+		var (
+			page                  string
+			onContentLoad, onLoad float64
+		)
+		if err := rows.Scan(&page, &onContentLoad, &onLoad); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", page, formatStat(onContentLoad), formatStat(onLoad))
+		count++
+	}
+	if count == 0 {
+		fmt.Fprintln(out, "Test has no HAR pages")
+		return
+	}
+	writer.Flush()
+}
+
+// getRequestPercentilesFromDB function returns sorted extra percentiles
+// stored by "recompute" command for a test along with their per-label values
+func getRequestPercentilesFromDB(DB dbExecutor, testID int64) ([]int, map[string]map[int]float64) {
+	rows, err := DB.Query(`
+SELECT label, percentile, value
+FROM request_percentiles
+WHERE test_id = ?
+ORDER BY percentile ASC;`, testID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	var percentiles []int
+	values := make(map[string]map[int]float64)
+	for rows.Next() {
+		var (
+			label string
+			perc  int
+			value float64
+		)
+		if err := rows.Scan(&label, &perc, &value); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		if len(percentiles) == 0 || percentiles[len(percentiles)-1] != perc {
+			percentiles = append(percentiles, perc)
+		}
+		if values[label] == nil {
+			values[label] = make(map[int]float64)
+		}
+		values[label][perc] = value
+	}
+
+	return percentiles, values
+}
+
+// getResponseCodesFromDB function returns amounts of responses per code
+// (or error name) of a test formatted as "code=count" pairs per label
+func getResponseCodesFromDB(DB dbExecutor, testID int64) map[string]string {
+	rows, err := DB.Query(`
+SELECT label, code, count
+FROM response_codes
+WHERE test_id = ?
+ORDER BY label ASC, code ASC;`, testID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	codes := make(map[string]string)
+	for rows.Next() {
+		var (
+			label, code string
+			count       int
+		)
+		if err := rows.Scan(&label, &code, &count); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		if codes[label] != "" {
+			codes[label] += " "
+		}
+		codes[label] += fmt.Sprintf("%s=%d", code, count)
+	}
+
+	return codes
+}
+
+// formatStat function formats statistic value without trailing zeros
+func formatStat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// validateShowArgs function validates arguments for "show" command
+func validateShowArgs(cmd *cobra.Command, args []string) error {
+	// validate argumets amount
+	if len(args) != 2 {
+		return errors.New("Please provide path to input DB file and test id or description")
+	}
+
+	// validate if input DB exists
+	if !dbExists(args[0]) {
+		return errors.New("Input file path is invalid or file does not exist")
+	}
+
+	// validate sort flag is valid
+	if !isOneOf(showSort, showSortList) {
+		return fmt.Errorf("Sort column is not one of the following: %v", showSortList)
+	}
+
+	return nil
+}
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   `show path/to/db/file "test id or description"`,
+	Short: "Show attributes and statistics of a test",
+	Long: `Show attributes of a single test along with its statistics.
+Load and HAR tests show per-label statistics in a table sorted by
+a selected column, along with amounts of responses per code or error
+for tests those are stored for. HAR tests also show page timings,
+WPT tests show metrics per view and step and Lighthouse tests show
+category scores and audit values.`,
+	Args: validateShowArgs,
+	Run:  showTest,
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringVarP(&showSort, "sort", "s", "label", fmt.Sprintf("Column to sort by: %v", showSortList))
+	showCmd.Flags().BoolVar(&showDesc, "desc", false, "Sort in descending order")
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dakaraj/ptrend/dbutils"
+)
+
+func TestShowingStatisticsByTestType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+
+	wptID, err := insertTest(DB, "wpt", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, metric := range []struct {
+		view, statistic string
+		value           float64
+	}{
+		{"first", "avg", 1200.5}, {"first", "med", 1100}, {"first", "run", 1000},
+		{"repeat", "avg", 600},
+	} {
+		_, err := DB.Exec(`
+INSERT INTO wpt_metrics (test_id, view, statistic, name, value)
+VALUES (?, ?, ?, 'SpeedIndex', ?);`, wptID, metric.view, metric.statistic, metric.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	lighthouseID, err := insertTest(DB, "lighthouse", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = DB.Exec(`
+INSERT INTO lighthouse_statistics (test_id, performance, seo, speed_index)
+VALUES (?, 0.9, 1, 2500);`, lighthouseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	harID, err := insertTest(DB, "har", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := HARPage{Title: "home"}
+	page.PageTimings.OnContentLoad, page.PageTimings.OnLoad = 300, 500.5
+	if err := insertHARPages(DB, harID, []HARPage{page}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	showWPTMetrics(&buffer, wptID)
+	expected := `View    Metric      Average  Median  Std
+first   SpeedIndex  1200.5   1100    -
+repeat  SpeedIndex  600      -       -
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected WPT metrics:\n%s", buffer.String())
+	}
+
+	buffer.Reset()
+	showLighthouseStatistics(&buffer, lighthouseID)
+	expected = `Audit                     Value
+performance               0.9
+accessibility             -
+best-practices            -
+seo                       1
+largest-contentful-paint  -
+total-blocking-time       -
+cumulative-layout-shift   -
+speed-index               2500
+interactive               -
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected Lighthouse audits:\n%s", buffer.String())
+	}
+
+	buffer.Reset()
+	showHARPages(&buffer, harID)
+	expected = `Page  onContentLoad  onLoad
+home  300            500.5
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected HAR pages:\n%s", buffer.String())
+	}
+
+	buffer.Reset()
+	showLighthouseStatistics(&buffer, wptID)
+	if buffer.String() != "Test has no Lighthouse audits\n" {
+		t.Errorf("Unexpected output for test without audits:\n%s", buffer.String())
+	}
+}
+
+func TestShowingResponseCodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	DB, err = dbutils.Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+	if err := dbutils.Initialize(DB); err != nil {
+		t.Fatal(err)
+	}
+	testID, err := insertTest(DB, "artillery", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = insertRequestStats(DB, testID, []RequestStats{
+		{Label: "/login", Samples: 100, Average: 120, Median: 110, Perc90: 150,
+			Perc95: 170, Perc99: 200, Min: 90, Max: 250},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := map[string]map[string]int{"/login": {"500": 2, "200": 98}}
+	if err := insertResponseCodes(DB, testID, codes); err != nil {
+		t.Fatal(err)
+	}
+
+	showSort = "label"
+	var buffer bytes.Buffer
+	showRequestStatistics(&buffer, testID)
+	expected := `Label   Samples  Average  Median  90%  95%  99%  Min  Max  Codes
+/login  100      120      110     150  170  200  90   250  200=98 500=2
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected request statistics:\n%s", buffer.String())
+	}
+}
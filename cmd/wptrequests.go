@@ -0,0 +1,181 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// wptBreakdownDimensions contains names of dimensions requests are grouped by
+var wptBreakdownDimensions = []string{"domain", "content_type"}
+
+// wptBreakdown struct contains totals of requests of a single domain
+// or content type
+type wptBreakdown struct {
+	requests float64
+	bytes    float64
+	loadTime float64
+}
+
+// wptRequestKeys function returns domain and content type of a request
+func wptRequestKeys(request map[string]interface{}) []string {
+	host, _ := request["host"].(string)
+	if host == "" {
+		host = "unknown"
+	}
+	contentType, _ := request["contentType"].(string)
+	// dropping parameters like charset
+	contentType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	if contentType == "" {
+		contentType = "unknown"
+	}
+
+	return []string{strings.ToLower(host), strings.ToLower(contentType)}
+}
+
+// wptViewRequests function returns all requests of a view. Top level
+// of multi-step view repeats the first step, so requests of every step
+// are returned instead, the same way metrics are split by wptViewSteps
+func wptViewRequests(viewStats map[string]interface{}) []map[string]interface{} {
+	var requests []map[string]interface{}
+	collect := func(data map[string]interface{}) {
+		list, _ := data["requests"].([]interface{})
+		for _, item := range list {
+			if request, ok := item.(map[string]interface{}); ok {
+				requests = append(requests, request)
+			}
+		}
+	}
+
+	steps, _ := viewStats["steps"].([]interface{})
+	if len(steps) < 2 {
+		collect(viewStats)
+		return requests
+	}
+	for _, step := range steps {
+		if stepStats, ok := step.(map[string]interface{}); ok {
+			collect(stepStats)
+		}
+	}
+
+	return requests
+}
+
+// aggregateWPTRequests function groups requests of every run by domain and
+// content type. Totals are averaged per run, so tests with different
+// amount of runs are comparable. Result is keyed by view and dimension
+func aggregateWPTRequests(runsData interface{}) map[string]map[string]map[string]*wptBreakdown {
+	result := map[string]map[string]map[string]*wptBreakdown{}
+	runs, ok := runsData.(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	for _, view := range wptViews {
+		var runsWithRequests int
+		breakdowns := map[string]map[string]*wptBreakdown{}
+		for _, dimension := range wptBreakdownDimensions {
+			breakdowns[dimension] = map[string]*wptBreakdown{}
+		}
+		for _, runData := range runs {
+			runViews, ok := runData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			viewStats, ok := runViews[view.key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			requests := wptViewRequests(viewStats)
+			if len(requests) == 0 {
+				continue
+			}
+			runsWithRequests++
+			for _, request := range requests {
+				bytes, ok := wptNumber(request["bytesIn"])
+				if !ok {
+					bytes, _ = wptNumber(request["objectSize"])
+				}
+				loadTime, ok := wptNumber(request["all_ms"])
+				if !ok {
+					loadTime, _ = wptNumber(request["load_ms"])
+				}
+				for i, key := range wptRequestKeys(request) {
+					dimension := wptBreakdownDimensions[i]
+					if breakdowns[dimension][key] == nil {
+						breakdowns[dimension][key] = &wptBreakdown{}
+					}
+					breakdowns[dimension][key].requests++
+					breakdowns[dimension][key].bytes += math.Max(bytes, 0)
+					breakdowns[dimension][key].loadTime += math.Max(loadTime, 0)
+				}
+			}
+		}
+		if runsWithRequests == 0 {
+			continue
+		}
+
+		for _, dimension := range breakdowns {
+			for _, breakdown := range dimension {
+				breakdown.requests = math.Round(breakdown.requests/float64(runsWithRequests)*100) / 100
+				breakdown.bytes = math.Round(breakdown.bytes / float64(runsWithRequests))
+				breakdown.loadTime = math.Round(breakdown.loadTime/float64(runsWithRequests)*100) / 100
+			}
+		}
+		result[view.name] = breakdowns
+	}
+
+	return result
+}
+
+// insertWPTBreakdowns function stores requests aggregated by domain
+// and content type for each view of a test
+func insertWPTBreakdowns(tx dbExecutor, testID int64, runsData interface{}) error {
+	// preparing an insert statement
+	insertStatement, err := tx.Prepare(`
+INSERT INTO wpt_breakdowns (
+	test_id, view, dimension, name, requests, bytes, load_time
+) VALUES (
+	?, ?, ?, ?, ?, ?, ?
+);`)
+	if err != nil {
+		return err
+	}
+	defer insertStatement.Close()
+
+	aggregated := aggregateWPTRequests(runsData)
+	for _, view := range wptViews {
+		for _, dimension := range wptBreakdownDimensions {
+			breakdowns := aggregated[view.name][dimension]
+			names := make([]string, 0, len(breakdowns))
+			for name := range breakdowns {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				b := breakdowns[name]
+				_, err := insertStatement.Exec(testID, view.name, dimension, name,
+					b.requests, b.bytes, b.loadTime)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAggregatingWPTRequests(t *testing.T) {
+	var runs interface{}
+	input := `{
+	"1": {"firstView": {"requests": [
+		{"host": "Example.com", "contentType": "text/html; charset=utf-8", "bytesIn": 1000, "load_ms": 100},
+		{"host": "cdn.example.com", "contentType": "image/png", "bytesIn": 4000, "load_ms": 300}
+	]}},
+	"2": {"firstView": {"requests": [
+		{"host": "example.com", "contentType": "text/html", "bytesIn": 2000, "load_ms": 200}
+	]}}
+}`
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&runs); err != nil {
+		t.Fatal(err)
+	}
+
+	result := aggregateWPTRequests(runs)
+	if _, ok := result["repeat"]; ok {
+		t.Error("Expected no breakdown for repeat view without requests")
+	}
+	domain := result["first"]["domain"]["example.com"]
+	if domain == nil || domain.requests != 1 || domain.bytes != 1500 || domain.loadTime != 150 {
+		t.Errorf("Unexpected domain breakdown: %+v", domain)
+	}
+	html := result["first"]["content_type"]["text/html"]
+	if html == nil || html.requests != 1 || html.bytes != 1500 {
+		t.Errorf("Unexpected content type breakdown: %+v", html)
+	}
+	png := result["first"]["content_type"]["image/png"]
+	if png == nil || png.requests != 0.5 || png.bytes != 2000 {
+		t.Errorf("Unexpected content type breakdown: %+v", png)
+	}
+
+	// top level of multi-step view repeats requests of the first step
+	input = `{
+	"1": {"firstView": {
+		"requests": [{"host": "example.com", "contentType": "text/html", "bytesIn": 1000}],
+		"steps": [
+			{"requests": [{"host": "example.com", "contentType": "text/html", "bytesIn": 1000}]},
+			{"requests": [{"host": "example.com", "contentType": "text/html", "bytesIn": 3000}]}
+		]
+	}}
+}`
+	decoder = json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&runs); err != nil {
+		t.Fatal(err)
+	}
+	domain = aggregateWPTRequests(runs)["first"]["domain"]["example.com"]
+	if domain == nil || domain.requests != 2 || domain.bytes != 4000 {
+		t.Errorf("Expected requests of every step to be counted once, got %+v", domain)
+	}
+}
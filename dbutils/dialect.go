@@ -0,0 +1,66 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbutils
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Dialect builds SQL expressions that differ between backends.
+// Queries should use it instead of functions of a particular backend
+type Dialect interface {
+	// FormatTime returns an expression formatting a timestamp
+	// as "YYYY-MM-DD HH:MM:SS" text
+	FormatTime(expr string) string
+	// SecondsBetween returns an expression of seconds passed
+	// between two timestamps
+	SecondsBetween(from, to string) string
+	// GroupConcat returns an aggregate expression joining values
+	// of a group into text with a separator
+	GroupConcat(expr, separator string) string
+}
+
+// DialectOf function returns dialect of a backend a DB was opened by
+func DialectOf(db *sql.DB) Dialect {
+	for _, b := range backends {
+		if b.owns(db) {
+			return b.dialect()
+		}
+	}
+
+	return sqliteDialect{}
+}
+
+// quote function returns a string literal of a value
+func quote(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// sqliteDialect builds expressions with SQLite core functions
+type sqliteDialect struct{}
+
+func (sqliteDialect) FormatTime(expr string) string {
+	return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:%%M:%%S', %s)", expr)
+}
+
+func (sqliteDialect) SecondsBetween(from, to string) string {
+	return fmt.Sprintf("((julianday(%s) - julianday(%s)) * 86400)", to, from)
+}
+
+func (sqliteDialect) GroupConcat(expr, separator string) string {
+	return fmt.Sprintf("GROUP_CONCAT(%s, %s)", expr, quote(separator))
+}
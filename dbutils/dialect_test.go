@@ -0,0 +1,119 @@
+package dbutils
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChoosingDialect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sqlite, err := Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+	if _, ok := DialectOf(sqlite).(sqliteDialect); !ok {
+		t.Errorf("Expected SQLite dialect, got %T", DialectOf(sqlite))
+	}
+
+	// no connection is made until a query is run
+	postgres, err := Open("postgres://ptrend@localhost/trends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer postgres.Close()
+	if _, ok := DialectOf(postgres).(postgresDialect); !ok {
+		t.Errorf("Expected PostgreSQL dialect, got %T", DialectOf(postgres))
+	}
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := Open(filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+CREATE TABLE periods (label VARCHAR(255), started DATETIME, finished DATETIME);
+INSERT INTO periods VALUES ('login', '2018-10-18 10:00:00', '2018-10-18 10:01:30');
+INSERT INTO periods VALUES ('it''s', '2018-10-18 11:00:00', '2018-10-18 11:00:10');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := sqliteDialect{}
+	var (
+		started string
+		seconds float64
+	)
+	err = db.QueryRow(`SELECT `+d.FormatTime("started")+`, `+d.SecondsBetween("started", "finished")+`
+FROM periods WHERE label = 'login';`).Scan(&started, &seconds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started != "2018-10-18 10:00:00" || math.Round(seconds) != 90 {
+		t.Errorf("Expected start time 2018-10-18 10:00:00 and 90 seconds, got %q and %v", started, seconds)
+	}
+
+	var labels string
+	err = db.QueryRow(`SELECT ` + d.GroupConcat("label", "', '") + `
+FROM (SELECT label FROM periods ORDER BY started) AS p;`).Scan(&labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels != "login', 'it's" {
+		t.Errorf("Expected labels joined with a quoted separator, got %q", labels)
+	}
+}
+
+func TestPostgresDialect(t *testing.T) {
+	d := postgresDialect{}
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{d.FormatTime("t.started"), "to_char(t.started, 'YYYY-MM-DD HH24:MI:SS')"},
+		{d.SecondsBetween("started", "finished"), "EXTRACT(EPOCH FROM (finished) - (started))"},
+		{d.GroupConcat("r.average", ","), "STRING_AGG(CAST(r.average AS TEXT), ',')"},
+		{d.GroupConcat("key", "it's"), "STRING_AGG(CAST(key AS TEXT), 'it''s')"},
+	}
+	for _, c := range cases {
+		if c.expr != c.expected {
+			t.Errorf("Expected %s, got %s", c.expected, c.expr)
+		}
+	}
+}
+
+func TestRebindingPlaceholders(t *testing.T) {
+	query := rebind(`SELECT * FROM tests WHERE description = ? AND build = '?' AND type_id IN (?, ?);`)
+	expected := `SELECT * FROM tests WHERE description = $1 AND build = '?' AND type_id IN ($2, $3);`
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+}
+
+func TestConvertingSchemasForPostgres(t *testing.T) {
+	for _, table := range postgresTables {
+		schema := postgresTypes.Replace(table)
+		for _, sqliteOnly := range []string{"AUTOINCREMENT", "DATETIME", "BLOB", "BOOLEAN"} {
+			if strings.Contains(schema, sqliteOnly) {
+				t.Errorf("Expected no %s in PostgreSQL schema:%s", sqliteOnly, schema)
+			}
+		}
+	}
+}
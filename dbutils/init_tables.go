@@ -16,20 +16,244 @@ package dbutils
 
 import (
 	"database/sql"
+	"fmt"
 )
 
-// Initialize function creates tables in DB based on schemas provided in schemas.go
+// migration struct represents a single schema change. Migrations are
+// applied in order and should be idempotent, so databases created before
+// schema was versioned are upgraded by applying all of them. PostgreSQL
+// databases are created with the latest schema, so only migrations added
+// after PostgreSQL was supported have a postgres function
+type migration struct {
+	description string
+	apply       func(tx *sql.Tx) error
+	postgres    func(tx *sql.Tx) error
+}
+
+// migrations contains all schema changes in order of their versions.
+// New migrations should only be appended to the end of the list
+var migrations = []migration{
+	{"create tables", createTables, nil},
+	{"add partial, external_id, perc99 and step columns", addColumns, nil},
+	{"migrate legacy WPT tables", migrateLegacyWPTTables, nil},
+	{"deduplicate test types", deduplicateTestTypes, nil},
+	{"add test metadata", addTestMetadata, nil},
+	{"add invalidated tests", addInvalidation, nil},
+	{"add raw samples", createTable(rawSamples), createTable(postgresTypes.Replace(rawSamples))},
+	{"add request percentiles", createTable(requestPercentiles), createTable(postgresTypes.Replace(requestPercentiles))},
+}
+
+// testTypes contains descriptions of test types by their ids
+var testTypes = []string{"load test", "web page test", "lighthouse", "har"}
+
+// SchemaVersion is a version of DB schema supported by this build
+var SchemaVersion = len(migrations)
+
+// Initialize function brings DB schema to the latest version.
+// Returns an error if DB was created by a newer build
 func Initialize(dbDriver *sql.DB) error {
-	statement, err := dbDriver.Prepare(testType)
+	for _, b := range backends {
+		if b.owns(dbDriver) {
+			return b.initialize(dbDriver)
+		}
+	}
+
+	return migrate(dbDriver)
+}
+
+// migrate function brings SQLite DB schema to the latest version
+// applying missing migrations
+func migrate(dbDriver *sql.DB) error {
+	if _, err := dbDriver.Exec(schemaVersion); err != nil {
+		return err
+	}
+
+	version, err := CurrentVersion(dbDriver)
 	if err != nil {
 		return err
 	}
-	statement.Exec()
-	dbDriver.Exec(testsTable)
-	dbDriver.Exec(requestStatisticsTable)
-	dbDriver.Exec(wptStatistics)
-	dbDriver.Exec(`INSERT INTO test_types (type_description) VALUES ('load test')`)
-	dbDriver.Exec(`INSERT INTO test_types (type_description) VALUES ('web page test')`)
+	if version > SchemaVersion {
+		return newerVersionError(version)
+	}
+
+	for i := version; i < SchemaVersion; i++ {
+		if err := applyMigration(dbDriver, i+1, migrations[i].description, migrations[i].apply); err != nil {
+			return fmt.Errorf("Failed to upgrade database schema to version %d (%s): %v",
+				i+1, migrations[i].description, err)
+		}
+	}
+
+	return nil
+}
+
+// newerVersionError function returns an error for DB created by a newer build
+func newerVersionError(version int) error {
+	return fmt.Errorf(
+		"Database schema version %d is newer than supported version %d, please upgrade ptrend",
+		version, SchemaVersion)
+}
+
+// CurrentVersion function returns version of DB schema, which is 0
+// for databases created before schema was versioned
+func CurrentVersion(dbDriver *sql.DB) (int, error) {
+	var version int
+	err := dbDriver.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version;`).Scan(&version)
+
+	return version, err
+}
+
+// applyMigration function applies a migration and records its version
+// in a single transaction
+func applyMigration(dbDriver *sql.DB, version int, description string, apply func(tx *sql.Tx) error) error {
+	tx, err := dbDriver.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := apply(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO schema_version (version, description) VALUES (?, ?);`,
+		version, description)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// createTables function creates tables based on schemas provided in schemas.go
+func createTables(tx *sql.Tx) error {
+	tables := []string{
+		testType, testsTable, requestStatisticsTable, responseCodes,
+		wptTests, wptMetrics, wptBreakdowns, lighthouseStatistics,
+		harPages, harTimings,
+	}
+	for _, table := range tables {
+		if _, err := tx.Exec(table); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
+
+// createTable function returns a migration creating a single table
+func createTable(schema string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(schema)
+		return err
+	}
+}
+
+// addColumns function adds columns introduced after tables were created
+// by earlier builds
+func addColumns(tx *sql.Tx) error {
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"tests", "partial", "BOOLEAN NOT NULL DEFAULT 0"},
+		{"tests", "external_id", "VARCHAR(255)"},
+		{"request_statistics", "perc99", "FLOAT NOT NULL DEFAULT 0"},
+		{"wpt_metrics", "step", "VARCHAR(255) NOT NULL DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if err := addColumn(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumn function adds a column to a table unless it already exists
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	exists, err := hasColumn(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, table, column, definition))
+
+	return err
+}
+
+// hasColumn function checks if a table has a column with a given name
+func hasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA table_info(%s);`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name         string
+			columnType   string
+			notNull      bool
+			defaultValue interface{}
+			primaryKey   int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// deduplicateTestTypes function replaces test types inserted on every
+// parse by earlier builds with a single row per type. Type ids are
+// referenced by parsers, so those are kept fixed. Rows are updated in
+// place, as removing a type referenced by tests would remove tests as well
+func deduplicateTestTypes(tx *sql.Tx) error {
+	if _, err := tx.Exec(`DELETE FROM test_types WHERE type_id > ?;`, len(testTypes)); err != nil {
+		return err
+	}
+	for i, description := range testTypes {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO test_types (type_id, type_description) VALUES (?, '');`, i+1)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE test_types SET type_description = ? WHERE type_id = ?;`, description, i+1)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS test_types_description ON test_types (type_description);`)
+
+	return err
+}
+
+// addTestMetadata function adds test period, environment, build and
+// revision columns along with a table of test tags
+func addTestMetadata(tx *sql.Tx) error {
+	for _, column := range []string{"started", "finished"} {
+		if err := addColumn(tx, "tests", column, "DATETIME"); err != nil {
+			return err
+		}
+	}
+	for _, column := range []string{"environment", "build", "revision"} {
+		if err := addColumn(tx, "tests", column, "VARCHAR(255)"); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(testTags)
+
+	return err
+}
+
+// addInvalidation function adds columns marking tests excluded from reports
+func addInvalidation(tx *sql.Tx) error {
+	if err := addColumn(tx, "tests", "invalid", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	return addColumn(tx, "tests", "invalid_reason", "VARCHAR(255)")
+}
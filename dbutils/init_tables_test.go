@@ -0,0 +1,47 @@
+package dbutils
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpgradingSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptrend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "trends.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// test types are duplicated by builds before schema was versioned
+	db.Exec(`CREATE TABLE test_types (type_id INTEGER PRIMARY KEY AUTOINCREMENT, type_description VARCHAR(64));`)
+	db.Exec(`INSERT INTO test_types (type_description) VALUES ('load test'), ('web page test'), ('load test');`)
+	for i := 0; i < 2; i++ {
+		if err := Initialize(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var types int
+	db.QueryRow(`SELECT COUNT(*) FROM test_types;`).Scan(&types)
+	if types != 4 {
+		t.Errorf("Expected 4 test types, got %d", types)
+	}
+	if version, _ := CurrentVersion(db); version != SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", SchemaVersion, version)
+	}
+
+	db.Exec(`INSERT INTO schema_version (version, description) VALUES (?, 'future');`,
+		SchemaVersion+1)
+	if err := Initialize(db); err == nil {
+		t.Error("Expected an error for newer schema version")
+	}
+}
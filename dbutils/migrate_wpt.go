@@ -0,0 +1,101 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbutils
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// legacyWPTColumns maps columns of fixed column WPT tables to metric names
+// used in WPT JSON
+var legacyWPTColumns = map[string]string{
+	"responses_200":                "responses_200",
+	"bytes_out":                    "bytesOut",
+	"gzip_savings":                 "gzip_savings",
+	"requests_full":                "requestsFull",
+	"connections":                  "connections",
+	"bytes_out_doc":                "bytesOutDoc",
+	"result":                       "result",
+	"base_page_ssl_time":           "basePageSSLTime",
+	"doc_time":                     "docTime",
+	"dom_content_loaded_event_end": "domContentLoadedEventEnd",
+	"image_savings":                "image_savings",
+	"requests_doc":                 "requestsDoc",
+	"first_text_paint":             "firstTextPaint",
+	"first_paint":                  "firstPaint",
+	"score_cdn":                    "score_cdn",
+	"cpu_idle":                     "cpu.Idle",
+	"optimization_checked":         "optimization_checked",
+	"image_total":                  "image_total",
+	"score_minify":                 "score_minify",
+	"gzip_total":                   "gzip_total",
+	"responses_404":                "responses_404",
+	"load_time":                    "loadTime",
+	"score_combine":                "score_combine",
+	"first_contentful_paint":       "firstContentfulPaint",
+	"first_layout":                 "firstLayout",
+	"score_etags":                  "score_etags",
+}
+
+// legacyWPTTables contains fixed column WPT tables with expressions
+// that select statistic kind and run number of their rows
+var legacyWPTTables = []struct {
+	table     string
+	statistic string
+	run       string
+}{
+	{"wpt_statistics", "metric", "0"},
+	{"wpt_runs", "'run'", "run"},
+}
+
+// migrateLegacyWPTTables function moves metrics stored in fixed column
+// WPT tables into wpt_metrics table as name/value rows and drops legacy tables
+func migrateLegacyWPTTables(tx *sql.Tx) error {
+	for _, legacy := range legacyWPTTables {
+		var exists int
+		err := tx.QueryRow(`
+SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;`,
+			legacy.table).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			continue
+		}
+
+		// tables created before repeat view was stored have no view column
+		if err := addColumn(tx, legacy.table, "view", "VARCHAR(6) NOT NULL DEFAULT 'first'"); err != nil {
+			return err
+		}
+
+		for column, name := range legacyWPTColumns {
+			_, err := tx.Exec(fmt.Sprintf(`
+INSERT INTO wpt_metrics (
+	test_id, view, statistic, run, name, value
+) SELECT test_id, view, %s, %s, ?, %s
+FROM %s;`, legacy.statistic, legacy.run, column, legacy.table), name)
+			if err != nil {
+				return fmt.Errorf("Failed to migrate %s.%s: %v", legacy.table, column, err)
+			}
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE %s;`, legacy.table)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
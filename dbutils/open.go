@@ -0,0 +1,107 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbutils
+
+import (
+	"database/sql"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3" // driver for sqlite3 database
+)
+
+// backend is a storage engine keeping trends database. A backend only
+// covers what differs between engines: opening a connection, creating
+// and migrating the schema, detecting unique violations and building
+// expressions by its Dialect. Queries of commands are not implemented per
+// backend, those run on *sql.DB returned by Open using "?" placeholders
+// and standard SQL, so those are written once for all engines
+type backend interface {
+	// accepts reports if a DSN or a file path belongs to the backend
+	accepts(dsn string) bool
+	// owns reports if a DB was opened by the backend
+	owns(db *sql.DB) bool
+	open(dsn string) (*sql.DB, error)
+	// initialize brings DB schema to the latest version
+	initialize(db *sql.DB) error
+	isUniqueViolation(err error) bool
+	dialect() Dialect
+}
+
+// backends contains supported storage engines, the last one accepts
+// everything not accepted by others
+var backends = []backend{postgresBackend{}, sqliteBackend{}}
+
+// Open function opens a database by a file path of SQLite database
+// or by a DSN of a database server, e.g. postgres://user@host/trends
+func Open(dsn string) (*sql.DB, error) {
+	for _, b := range backends {
+		if b.accepts(dsn) {
+			return b.open(dsn)
+		}
+	}
+
+	return sqliteBackend{}.open(dsn)
+}
+
+// IsDSN function reports if a database is kept by a database server
+// rather than in a file
+func IsDSN(dsn string) bool {
+	return postgresBackend{}.accepts(dsn)
+}
+
+// IsUniqueViolation function reports if an error is caused by
+// a duplicate value of a unique column
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, b := range backends {
+		if b.isUniqueViolation(err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sqliteBackend keeps trends database in a single SQLite file
+type sqliteBackend struct{}
+
+func (sqliteBackend) accepts(dsn string) bool {
+	return true
+}
+
+func (sqliteBackend) owns(db *sql.DB) bool {
+	_, ok := db.Driver().(*sqlite3.SQLiteDriver)
+	return ok
+}
+
+// open function opens SQLite database file with foreign keys enforced
+// on every connection, so removing a test removes all of its data
+func (sqliteBackend) open(path string) (*sql.DB, error) {
+	return sql.Open("sqlite3", path+"?_foreign_keys=on")
+}
+
+func (sqliteBackend) initialize(db *sql.DB) error {
+	return migrate(db)
+}
+
+func (sqliteBackend) isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint")
+}
+
+func (sqliteBackend) dialect() Dialect {
+	return sqliteDialect{}
+}
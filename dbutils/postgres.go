@@ -0,0 +1,195 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbutils
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq" // driver for PostgreSQL database
+)
+
+// postgresDriverName is a name of PostgreSQL driver translating
+// "?" placeholders used by queries
+const postgresDriverName = "ptrend-postgres"
+
+// postgresTables contains schemas of all tables created in PostgreSQL
+var postgresTables = []string{
+	testType, testsTable, requestStatisticsTable, responseCodes,
+	wptTests, wptMetrics, wptBreakdowns, lighthouseStatistics,
+	harPages, harTimings, testTags, rawSamples, requestPercentiles,
+}
+
+// postgresTypes converts column types of SQLite schemas
+var postgresTypes = strings.NewReplacer(
+	"INTEGER PRIMARY KEY AUTOINCREMENT", "SERIAL PRIMARY KEY",
+	"DATETIME", "TIMESTAMP",
+	"BOOLEAN NOT NULL DEFAULT 0", "SMALLINT NOT NULL DEFAULT 0",
+	"BLOB", "BYTEA",
+)
+
+func init() {
+	sql.Register(postgresDriverName, postgresDriver{})
+}
+
+// postgresBackend keeps trends database in PostgreSQL shared by teams
+type postgresBackend struct{}
+
+func (postgresBackend) accepts(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+func (postgresBackend) owns(db *sql.DB) bool {
+	_, ok := db.Driver().(postgresDriver)
+	return ok
+}
+
+func (postgresBackend) open(dsn string) (*sql.DB, error) {
+	return sql.Open(postgresDriverName, dsn)
+}
+
+// initialize function creates the latest schema in an empty database
+// or applies migrations added after PostgreSQL was supported
+func (postgresBackend) initialize(db *sql.DB) error {
+	if _, err := db.Exec(postgresTypes.Replace(schemaVersion)); err != nil {
+		return err
+	}
+	version, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return newerVersionError(version)
+	}
+	if version > 0 {
+		for i := version; i < SchemaVersion; i++ {
+			m := migrations[i]
+			if m.postgres == nil {
+				return fmt.Errorf("Upgrading PostgreSQL database schema to version %d (%s) is not supported",
+					i+1, m.description)
+			}
+			if err := applyMigration(db, i+1, m.description, m.postgres); err != nil {
+				return fmt.Errorf("Failed to upgrade database schema to version %d (%s): %v",
+					i+1, m.description, err)
+			}
+		}
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var statements []string
+	for _, table := range postgresTables {
+		statements = append(statements, postgresTypes.Replace(table))
+	}
+	statements = append(statements,
+		`CREATE UNIQUE INDEX IF NOT EXISTS test_types_description ON test_types (type_description);`)
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	for i, description := range testTypes {
+		_, err := tx.Exec(`INSERT INTO test_types (type_id, type_description) VALUES (?, ?);`,
+			i+1, description)
+		if err != nil {
+			return err
+		}
+	}
+	for i, m := range migrations {
+		_, err := tx.Exec(`INSERT INTO schema_version (version, description) VALUES (?, ?);`,
+			i+1, m.description)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (postgresBackend) isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+func (postgresBackend) dialect() Dialect {
+	return postgresDialect{}
+}
+
+// postgresDialect builds expressions with PostgreSQL functions
+type postgresDialect struct{}
+
+func (postgresDialect) FormatTime(expr string) string {
+	return fmt.Sprintf("to_char(%s, 'YYYY-MM-DD HH24:MI:SS')", expr)
+}
+
+func (postgresDialect) SecondsBetween(from, to string) string {
+	return fmt.Sprintf("EXTRACT(EPOCH FROM (%s) - (%s))", to, from)
+}
+
+func (postgresDialect) GroupConcat(expr, separator string) string {
+	return fmt.Sprintf("STRING_AGG(CAST(%s AS TEXT), %s)", expr, quote(separator))
+}
+
+// postgresDriver wraps PostgreSQL driver numbering "?" placeholders
+// of queries, so the same queries are run by all backends. Only
+// preparing statements is exposed, as all queries have to be translated
+type postgresDriver struct{}
+
+func (postgresDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := pq.Driver{}.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return postgresConn{conn}, nil
+}
+
+type postgresConn struct {
+	driver.Conn
+}
+
+func (c postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+// rebind function replaces "?" placeholders outside of string
+// literals with numbered ones
+func rebind(query string) string {
+	var (
+		b      strings.Builder
+		n      int
+		quoted bool
+	)
+	for _, r := range query {
+		if r == '\'' {
+			quoted = !quoted
+		}
+		if r == '?' && !quoted {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
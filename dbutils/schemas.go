@@ -14,6 +14,13 @@
 
 package dbutils
 
+const schemaVersion = `
+CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	description VARCHAR(255) NOT NULL,
+	applied DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+
 const testType = `
 CREATE TABLE IF NOT EXISTS test_types (
 	type_id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -25,6 +32,15 @@ CREATE TABLE IF NOT EXISTS tests (
 	test_id INTEGER PRIMARY KEY AUTOINCREMENT,
 	description VARCHAR(255) UNIQUE NOT NULL,
 	type_id INT NOT NULL,
+	partial BOOLEAN NOT NULL DEFAULT 0,
+	external_id VARCHAR(255),
+	started DATETIME,
+	finished DATETIME,
+	environment VARCHAR(255),
+	build VARCHAR(255),
+	revision VARCHAR(255),
+	invalid BOOLEAN NOT NULL DEFAULT 0,
+	invalid_reason VARCHAR(255),
 	FOREIGN KEY (type_id) REFERENCES test_types(type_id) ON DELETE CASCADE
 );`
 
@@ -38,41 +54,124 @@ CREATE TABLE IF NOT EXISTS request_statistics (
 	median FLOAT NOT NULL,
 	perc90 FLOAT NOT NULL,
 	perc95 FLOAT NOT NULL,
+	perc99 FLOAT NOT NULL DEFAULT 0,
 	min INT NOT NULL,
 	max INT NOT NULL,
 	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
 );`
 
-const wptStatistics = `
-CREATE TABLE IF NOT EXISTS wpt_statistics (
-	wpt_id INTEGER PRIMARY KEY AUTOINCREMENT,
+const lighthouseStatistics = `
+CREATE TABLE IF NOT EXISTS lighthouse_statistics (
+	lighthouse_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	test_id INT NOT NULL,
+	performance FLOAT,
+	accessibility FLOAT,
+	best_practices FLOAT,
+	seo FLOAT,
+	largest_contentful_paint FLOAT,
+	total_blocking_time FLOAT,
+	cumulative_layout_shift FLOAT,
+	speed_index FLOAT,
+	interactive FLOAT,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const harPages = `
+CREATE TABLE IF NOT EXISTS har_pages (
+	page_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	test_id INT NOT NULL,
+	page VARCHAR(255) NOT NULL,
+	on_content_load FLOAT NOT NULL,
+	on_load FLOAT NOT NULL,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const harTimings = `
+CREATE TABLE IF NOT EXISTS har_timings (
+	timing_id INTEGER PRIMARY KEY AUTOINCREMENT,
 	test_id INT NOT NULL,
-	metric VARCHAR(3) CHECK (metric IN ('avg', 'std', 'med')) NOT NULL,
-	responses_200 FLOAT NOT NULL,
-	bytes_out FLOAT NOT NULL,
-	gzip_savings FLOAT NOT NULL,
-	requests_full FLOAT NOT NULL,
-	connections FLOAT NOT NULL,
-	bytes_out_doc FLOAT NOT NULL,
-	result FLOAT NOT NULL,
-	base_page_ssl_time FLOAT NOT NULL,
-	doc_time FLOAT NOT NULL,
-	dom_content_loaded_event_end FLOAT NOT NULL,
-	image_savings FLOAT NOT NULL,
-	requests_doc FLOAT NOT NULL,
-	first_text_paint FLOAT NOT NULL,
-	first_paint FLOAT NOT NULL,
-	score_cdn FLOAT NOT NULL,
-	cpu_idle FLOAT NOT NULL,
-	optimization_checked FLOAT NOT NULL,
-	image_total FLOAT NOT NULL,
-	score_minify FLOAT NOT NULL,
-	gzip_total FLOAT NOT NULL,
-	responses_404 FLOAT NOT NULL,
+	url VARCHAR(255) NOT NULL,
+	requests INT NOT NULL,
+	blocked FLOAT NOT NULL,
+	dns FLOAT NOT NULL,
+	connect FLOAT NOT NULL,
+	ssl FLOAT NOT NULL,
+	send FLOAT NOT NULL,
+	wait FLOAT NOT NULL,
+	receive FLOAT NOT NULL,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const responseCodes = `
+CREATE TABLE IF NOT EXISTS response_codes (
+	code_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	test_id INT NOT NULL,
+	label VARCHAR(255) NOT NULL,
+	code VARCHAR(64) NOT NULL,
+	count INT NOT NULL,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const wptMetrics = `
+CREATE TABLE IF NOT EXISTS wpt_metrics (
+	wpt_metric_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	test_id INT NOT NULL,
+	view VARCHAR(6) CHECK (view IN ('first', 'repeat')) NOT NULL,
+	statistic VARCHAR(3) CHECK (statistic IN ('avg', 'std', 'med', 'run')) NOT NULL,
+	run INT NOT NULL DEFAULT 0,
+	step VARCHAR(255) NOT NULL DEFAULT '',
+	name VARCHAR(255) NOT NULL,
+	value FLOAT NOT NULL,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const wptTests = `
+CREATE TABLE IF NOT EXISTS wpt_tests (
+	test_id INTEGER PRIMARY KEY,
+	url VARCHAR(2048) NOT NULL,
+	location VARCHAR(255) NOT NULL,
+	connectivity VARCHAR(64) NOT NULL,
+	browser VARCHAR(64) NOT NULL,
+	completed DATETIME,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const wptBreakdowns = `
+CREATE TABLE IF NOT EXISTS wpt_breakdowns (
+	wpt_breakdown_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	test_id INT NOT NULL,
+	view VARCHAR(6) CHECK (view IN ('first', 'repeat')) NOT NULL,
+	dimension VARCHAR(12) CHECK (dimension IN ('domain', 'content_type')) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	requests FLOAT NOT NULL,
+	bytes FLOAT NOT NULL,
 	load_time FLOAT NOT NULL,
-	score_combine FLOAT NOT NULL,
-	first_contentful_paint FLOAT NOT NULL,
-	first_layout FLOAT NOT NULL,
-	score_etags FLOAT NOT NULL,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const testTags = `
+CREATE TABLE IF NOT EXISTS test_tags (
+	test_id INT NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	value VARCHAR(255) NOT NULL,
+	PRIMARY KEY (test_id, key),
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const rawSamples = `
+CREATE TABLE IF NOT EXISTS raw_samples (
+	test_id INTEGER PRIMARY KEY,
+	samples INT NOT NULL,
+	data BLOB NOT NULL,
+	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
+);`
+
+const requestPercentiles = `
+CREATE TABLE IF NOT EXISTS request_percentiles (
+	test_id INT NOT NULL,
+	label VARCHAR(255) NOT NULL,
+	percentile INT NOT NULL,
+	value FLOAT NOT NULL,
+	PRIMARY KEY (test_id, label, percentile),
 	FOREIGN KEY (test_id) REFERENCES tests(test_id) ON DELETE CASCADE
 );`
@@ -0,0 +1,337 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templates
+
+// LighthouseTemplate represents a template for a generated page with Lighthouse stats
+const LighthouseTemplate = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8" />
+    <meta http-equiv="X-UA-Compatible" content="IE=edge" />
+    <title>Lighthouse Trends Report</title>
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <script src="https://d3js.org/d3.v5.min.js"></script>
+    <style>` + metricsStyle + `</style>
+  </head>
+  <body>
+    <div>
+      <div class="controls">
+        <div>Compare tests:</div>
+        <ul id="comparison-list"></ul>
+        <button onclick="compare()">Compare</button>
+        <button onclick="resetTable()">Reset</button>
+      </div>
+      <div class="bar-container" style="display: none"></div>
+      <h1>Lighthouse scores and metrics per test</h1>
+      <table id="trends-table">
+        <thead>
+          <tr id="header-row"></tr>
+        </thead>
+        <tbody id="trends-table-body"></tbody>
+      </table>
+    </div>
+  </body>
+  <script src="main.js"></script>
+</html>
+`
+
+// HARTemplate represents a template for a generated page with HAR timings
+const HARTemplate = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8" />
+    <meta http-equiv="X-UA-Compatible" content="IE=edge" />
+    <title>HAR Trends Report</title>
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <script src="https://d3js.org/d3.v5.min.js"></script>
+    <style>` + metricsStyle + `</style>
+  </head>
+  <body>
+    <div>
+      <div class="controls">
+        <div>Compare tests:</div>
+        <ul id="comparison-list"></ul>
+        <button onclick="compare()">Compare</button>
+        <button onclick="resetTable()">Reset</button>
+      </div>
+      <div class="bar-container" style="display: none"></div>
+      <h1>HAR page timings and timing phases of requests per test</h1>
+      <table id="trends-table">
+        <thead>
+          <tr id="header-row"></tr>
+        </thead>
+        <tbody id="trends-table-body"></tbody>
+      </table>
+    </div>
+  </body>
+  <script src="main.js"></script>
+</html>
+`
+
+// metricsStyle contains styles shared by per-metric reports
+const metricsStyle = `
+      table,
+      th,
+      td {
+        border: 1px solid black;
+      }
+      .high {
+        background-color: pink;
+      }
+      .controls {
+        display: inline-block;
+      }
+      #comparison-list {
+        list-style-type: none;
+        margin: 0;
+        padding: 0;
+        max-height: 100px;
+        overflow-y: auto;
+        overflow-x: hidden;
+        border: 1px solid black;
+      }
+      tr > td:first-child,
+      tr > th:first-child {
+        width: 400px;
+        max-width: 400px;
+        overflow: hidden;
+        white-space: nowrap;
+        text-overflow: ellipsis;
+      }
+      #trends-table-body td:nth-child(n + 2) {
+        width: 120px;
+        max-width: 120px;
+        text-align: center;
+      }
+      #header-row > th:nth-child(n + 2) {
+        cursor: pointer;
+      }
+      .bar-container {
+        padding: 2px 0px 4px 0px;
+        position: absolute;
+        display: block;
+        height: 100px;
+        border: 2px solid gray;
+        min-width: 20px;
+        background-color: beige;
+      }
+      .bar {
+        display: inline-block;
+        background-color: green;
+        width: 15px;
+        border: 1px solid darkgreen;
+        margin: 0 2px;
+      }
+    `
+
+// MetricsJS contains logic of a page where each row is a single metric
+// and each column is a test
+const MetricsJS = `let data = {{data}};
+
+function headerPopulate() {
+    let headerRow = d3.select("#header-row");
+    let comparisonList = d3.select("#comparison-list");
+
+    comparisonList.html("");
+    headerRow.html(
+        "<th><span>Test Description</span><hr/><span>Metric</span></th>"
+    );
+
+    headerRow
+        .selectAll("th:nth-child(n+2)")
+        .data(data.tests)
+        .enter()
+    .append("th")
+        .on("click", function(_, i) {
+            sortByColValue(i);
+        })
+        .attr("title", (_, i) => data.details[i])
+        .text(d => d);
+
+    comparisonList
+        .selectAll("li")
+        .data(data.tests)
+        .enter()
+    .append("li")
+        .html(
+            (d, i) =>
+                ` + "`<label for=\"chk${i}\"><input type=\"checkbox\" name=\"chk${i}\" id=\"chk${i}\">${d}</label>`" + `
+        );
+}
+
+function medianCalculator(stats) {
+    if (stats.length == 1) {
+        return stats[0];
+    }
+    let rank = 0.5 * (stats.length - 1) + 1;
+    let ir = Math.floor(rank);
+    let fr = rank - ir;
+
+    return fr * (stats[ir] - stats[ir-1]) + stats[ir-1];
+}
+
+function isWorse(d, val, baseline) {
+    return d.higherIsBetter ? val < baseline : val > baseline;
+}
+
+function displayBarChart(d, visible) {
+  let maxVal = Math.max(...d.values);
+  let div = d3.select("div.bar-container");
+  div.html("");
+  if (visible) {
+    div
+      .style("display", "block")
+      .style("top", ` + "`${cursorY - 130}px`" + `)
+      .style("left", ` + "`${cursorX}px`" + `)
+      .selectAll("div")
+      .data(d.values)
+      .enter()
+      .append("div")
+      .attr("class", "bar")
+      .style("height", dd => ` + "`${(dd / maxVal) * 100}%`" + `);
+  } else {
+    div.style("display", "none");
+  }
+}
+
+function rowsPopulate() {
+    headerPopulate();
+    let tBody = d3.select("#trends-table-body");
+    tBody.html("");
+
+    tBody
+        .selectAll("tr")
+        .data(data.results)
+        .enter()
+    .append("tr")
+        .on("mouseover", function(d) {
+            displayBarChart(d, true);
+        })
+        .on("mouseout", function(d) {
+            displayBarChart(d, false);
+        })
+        .html(function(d) {
+            let row = ` + "`<td title=\"${d.label}\">${d.label}</td>`" + `;
+            // missing values are null, zero is a valid value
+            let validValues = d.values.filter(val => val !== null);
+            validValues.sort((a, b) => a - b);
+            let rowMedian = medianCalculator(validValues);
+            d.values.forEach(function(s) {
+                if (s === null) {
+                    row += "<td>-</td>";
+                } else if (isWorse(d, s, rowMedian)) {
+                    row += ` + "`<td class=\"high\">${s}</td>`" + `;
+                } else {
+                    row += ` + "`<td>${s}</td>`" + `;
+                }
+            });
+
+            return row;
+        });
+}
+
+function resetTable() {
+    rowsPopulate();
+}
+
+function compare() {
+    let testsArray = document.querySelectorAll("#comparison-list input:checked");
+    if (testsArray.length < 2) {
+        alert("Select at least two tests");
+        return;
+    }
+    let idxs = [];
+    testsArray.forEach(function(elem) {
+        idxs.push(parseInt(elem.id.slice(3)));
+    });
+    let headerRow = d3.select("#header-row");
+    let tBody = d3.select("#trends-table-body");
+    headerRow.html(
+        "<th><span>Test Description</span><hr/><span>Metric</span></th>"
+    );
+    tBody.html("");
+    headerRow
+        .selectAll("th:nth-child(n+2)")
+        .data(idxs)
+        .enter()
+    .append("th")
+        .on("click", function(_, i) {
+            sortByColValue(i);
+        })
+        .attr("title", d => data.details[d])
+        .text(d => data.tests[d]);
+
+    tBody
+        .selectAll("tr")
+        .data(data.results)
+        .enter()
+    .append("tr")
+        .html(function(d) {
+            let row = ` + "`<td title=\"${d.label}\">${d.label}</td>`" + `;
+            let baselineVal = undefined;
+            idxs.forEach(function (idx) {
+                let val = d.values[idx];
+                if (baselineVal === undefined) {
+                    baselineVal = val;
+                    row += val === null ? "<td>-</td>" : ` + "`<td>${val}</td>`" + `;
+                    return;
+                }
+                if (val === null) {
+                    row += "<td>-</td>";
+                } else if (baselineVal === null || baselineVal == 0 || val == baselineVal) {
+                    row += ` + "`<td>${val}</td>`" + `;
+                } else {
+                    let diff = Math.round((val / baselineVal - 1) * 100);
+                    let color = isWorse(d, val, baselineVal) ? "red" : "green";
+                    row += ` + "`<td>${val} <span style=\"color: ${color}\">(${diff}%)</span></td>`" + `;
+                }
+            });
+
+            return row;
+        });
+}
+
+function sortByColValue(idx) {
+    let sortClass = document.querySelectorAll("#header-row > th")[idx + 1].classList;
+    let allRows = document.querySelectorAll("#trends-table-body > tr");
+    let allRowsArray = Array.from(allRows);
+    let emptyElems = allRowsArray.filter((elem) => elem.childNodes[idx + 1].innerText.trim() == "-");
+    allRowsArray = allRowsArray.filter((elem) => elem.childNodes[idx + 1].innerText.trim() != "-");
+    let orderDesc = sortClass.contains("desc");
+    document.querySelectorAll("#header-row > th:nth-child(n+2)").forEach((elem) => elem.classList.remove("desc"));
+    let pattern = /^([\d\.]+)/;
+    allRowsArray.sort(function (a, b) {
+        let aText = pattern.exec(a.childNodes[idx + 1].innerText)[1];
+        let bText = pattern.exec(b.childNodes[idx + 1].innerText)[1];
+        return orderDesc ? aText - bText : bText - aText;
+    });
+    if (!orderDesc) {
+        sortClass.add("desc");
+    }
+    let tableBody = document.querySelector("#trends-table-body");
+    tableBody.innerHTML = "";
+    allRowsArray.forEach((elem) => tableBody.appendChild(elem));
+    emptyElems.forEach((elem) => tableBody.appendChild(elem));
+}
+
+window.onload = rowsPopulate();
+
+var cursorX;
+var cursorY;
+document.onmousemove = function(e) {
+  cursorX = e.pageX;
+  cursorY = e.pageY;
+};
+`
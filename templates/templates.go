@@ -87,6 +87,7 @@ const JmeterTemplate = `<!DOCTYPE html>
           <option value="median">Median</option>
           <option value="perc90">90 Percentile</option>
           <option value="perc95">95 Percentile</option>
+          <option value="perc99">99 Percentile</option>
           <option value="min">Min</option>
           <option value="max">Max</option>
         </select>
@@ -129,6 +130,7 @@ function headerPopulate() {
         .on("click", function(_, i) {
             sortByColValue(i);
         })
+        .attr("title", (_, i) => data.details[i])
         .text(d => d);
 
     comparisonList
@@ -236,6 +238,7 @@ function compare() {
 		.data(idxs)
 		.enter()
 	.append("th")
+		.attr("title", d => data.details[d])
 		.html((d, i) => ` + "`<th onclick=\"sortByColValue(${i})\">${data.tests[d]}</th>`" + `);
 
 	tBody
@@ -0,0 +1,350 @@
+// Copyright © 2018 Anton Kramarev <kramarev.anton@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templates
+
+// WPTTemplate represents a template for a generated page with WebPageTest stats
+const WPTTemplate = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8" />
+    <meta http-equiv="X-UA-Compatible" content="IE=edge" />
+    <title>WebPageTest Trends Report</title>
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <script src="https://d3js.org/d3.v5.min.js"></script>
+    <style>` + metricsStyle + `
+      .bar-slot {
+        display: inline-block;
+        position: relative;
+        height: 100%;
+        width: 19px;
+      }
+      .bar-slot > .bar {
+        position: absolute;
+        bottom: 0;
+      }
+      .cwv-good {
+        background-color: lightgreen;
+      }
+      .cwv-average {
+        background-color: khaki;
+      }
+      .cwv-poor {
+        background-color: lightcoral;
+      }
+      .band {
+        position: absolute;
+        left: 0;
+        width: 19px;
+        background-color: rgba(255, 165, 0, 0.5);
+      }
+    </style>
+  </head>
+  <body>
+    <div>
+      <div class="controls">
+        <div>Select view:</div>
+        <select name="view" id="view-selector" onchange="rowsPopulate()">
+          <option value="first" selected>First view</option>
+          <option value="repeat">Repeat view</option>
+        </select>
+        <div>Select data:</div>
+        <select name="section" id="section-selector" onchange="rowsPopulate()">
+          <option value="metrics" selected>Metrics (median ± std)</option>
+          <option value="vitals">Core Web Vitals (median ± std)</option>
+          <option value="domain:bytes">Bytes per domain</option>
+          <option value="domain:requests">Requests per domain</option>
+          <option value="domain:loadTime">Load time per domain, ms</option>
+          <option value="content_type:bytes">Bytes per content type</option>
+          <option value="content_type:requests">Requests per content type</option>
+          <option value="content_type:loadTime">Load time per content type, ms</option>
+        </select>
+        <div>Compare tests:</div>
+        <ul id="comparison-list"></ul>
+        <button onclick="compare()">Compare</button>
+        <button onclick="resetTable()">Reset</button>
+      </div>
+      <div class="bar-container" style="display: none"></div>
+      <h1 id="title"></h1>
+      <table id="trends-table">
+        <thead>
+          <tr id="header-row"></tr>
+        </thead>
+        <tbody id="trends-table-body"></tbody>
+      </table>
+    </div>
+  </body>
+  <script src="main.js"></script>
+</html>
+`
+
+// WPTJS contains logic of a WebPageTest report page where each row is
+// a metric or a breakdown entry of selected view and each column is a test
+const WPTJS = `let data = {{data}};
+
+function currentRows() {
+    let view = data.views[document.querySelector("#view-selector").value];
+    let section = document.querySelector("#section-selector").value;
+    if (!view) {
+        return [];
+    }
+    if (section == "metrics") {
+        return view.metrics || [];
+    }
+    if (section == "vitals") {
+        return (view.metrics || []).filter(d => d.thresholds);
+    }
+    let [dimension, field] = section.split(":");
+
+    return (view[dimension] || []).map(d => ({
+        label: d.label,
+        higherIsBetter: false,
+        values: d[field]
+    }));
+}
+
+function headerPopulate() {
+    let headerRow = d3.select("#header-row");
+    let comparisonList = d3.select("#comparison-list");
+    let selector = document.querySelector("#section-selector");
+
+    d3.select("#title").text(
+        ` + "`${selector.options[selector.selectedIndex].text} per test`" + `
+    );
+    comparisonList.html("");
+    headerRow.html(
+        "<th><span>Test Description</span><hr/><span>Metric</span></th>"
+    );
+
+    headerRow
+        .selectAll("th:nth-child(n+2)")
+        .data(data.tests)
+        .enter()
+    .append("th")
+        .on("click", function(_, i) {
+            sortByColValue(i);
+        })
+        .attr("title", (_, i) => data.details[i])
+        .text(d => d);
+
+    comparisonList
+        .selectAll("li")
+        .data(data.tests)
+        .enter()
+    .append("li")
+        .html(
+            (d, i) =>
+                ` + "`<label for=\"chk${i}\"><input type=\"checkbox\" name=\"chk${i}\" id=\"chk${i}\">${d}</label>`" + `
+        );
+}
+
+function medianCalculator(stats) {
+    if (stats.length == 1) {
+        return stats[0];
+    }
+    let rank = 0.5 * (stats.length - 1) + 1;
+    let ir = Math.floor(rank);
+    let fr = rank - ir;
+
+    return fr * (stats[ir] - stats[ir-1]) + stats[ir-1];
+}
+
+function isWorse(d, val, baseline) {
+    return d.higherIsBetter ? val < baseline : val > baseline;
+}
+
+// vitalClass returns a class of Core Web Vitals rating of a value
+// or an empty string if metric has no thresholds or value is missing
+function vitalClass(d, val) {
+    if (!d.thresholds || val === null) {
+        return "";
+    }
+    if (val <= d.thresholds[0]) {
+        return "cwv-good";
+    }
+
+    return val <= d.thresholds[1] ? "cwv-average" : "cwv-poor";
+}
+
+function formatValue(d, idx) {
+    let val = d.values[idx];
+    if (d.std && d.std[idx]) {
+        return ` + "`${val} ± ${d.std[idx]}`" + `;
+    }
+
+    return ` + "`${val}`" + `;
+}
+
+function displayBarChart(d, visible) {
+  let std = d.std || [];
+  let maxVal = Math.max(...d.values.map((v, i) => v + (std[i] || 0)));
+  let div = d3.select("div.bar-container");
+  div.html("");
+  if (!visible || maxVal == 0) {
+    div.style("display", "none");
+    return;
+  }
+  let slots = div
+    .style("display", "block")
+    .style("top", ` + "`${cursorY - 130}px`" + `)
+    .style("left", ` + "`${cursorX}px`" + `)
+    .selectAll("div")
+    .data(d.values.map((v, i) => ({ value: v, std: std[i] || 0 })))
+    .enter()
+    .append("div")
+    .attr("class", "bar-slot");
+  slots
+    .append("div")
+    .attr("class", "bar")
+    .style("height", dd => ` + "`${(dd.value / maxVal) * 100}%`" + `);
+  // band shows median ± standard deviation of WPT runs
+  slots
+    .filter(dd => dd.std > 0)
+    .append("div")
+    .attr("class", "band")
+    .style("bottom", dd => ` + "`${(Math.max(dd.value - dd.std, 0) / maxVal) * 100}%`" + `)
+    .style("height", dd => ` + "`${((Math.min(dd.std, dd.value) + dd.std) / maxVal) * 100}%`" + `);
+}
+
+function rowsPopulate() {
+    headerPopulate();
+    let tBody = d3.select("#trends-table-body");
+    tBody.html("");
+
+    tBody
+        .selectAll("tr")
+        .data(currentRows())
+        .enter()
+    .append("tr")
+        .on("mouseover", function(d) {
+            displayBarChart(d, true);
+        })
+        .on("mouseout", function(d) {
+            displayBarChart(d, false);
+        })
+        .html(function(d) {
+            let row = ` + "`<td title=\"${d.label}\">${d.label}</td>`" + `;
+            // missing values are null, zero is a valid value
+            let validValues = d.values.filter(val => val !== null);
+            validValues.sort((a, b) => a - b);
+            let rowMedian = medianCalculator(validValues);
+            d.values.forEach(function(s, i) {
+                if (s === null) {
+                    row += "<td>-</td>";
+                } else if (d.thresholds) {
+                    row += ` + "`<td class=\"${vitalClass(d, s)}\">${formatValue(d, i)}</td>`" + `;
+                } else if (isWorse(d, s, rowMedian)) {
+                    row += ` + "`<td class=\"high\">${formatValue(d, i)}</td>`" + `;
+                } else {
+                    row += ` + "`<td>${formatValue(d, i)}</td>`" + `;
+                }
+            });
+
+            return row;
+        });
+}
+
+function resetTable() {
+    rowsPopulate();
+}
+
+function compare() {
+    let testsArray = document.querySelectorAll("#comparison-list input:checked");
+    if (testsArray.length < 2) {
+        alert("Select at least two tests");
+        return;
+    }
+    let idxs = [];
+    testsArray.forEach(function(elem) {
+        idxs.push(parseInt(elem.id.slice(3)));
+    });
+    let headerRow = d3.select("#header-row");
+    let tBody = d3.select("#trends-table-body");
+    headerRow.html(
+        "<th><span>Test Description</span><hr/><span>Metric</span></th>"
+    );
+    tBody.html("");
+    headerRow
+        .selectAll("th:nth-child(n+2)")
+        .data(idxs)
+        .enter()
+    .append("th")
+        .on("click", function(_, i) {
+            sortByColValue(i);
+        })
+        .attr("title", d => data.details[d])
+        .text(d => data.tests[d]);
+
+    tBody
+        .selectAll("tr")
+        .data(currentRows())
+        .enter()
+    .append("tr")
+        .html(function(d) {
+            let row = ` + "`<td title=\"${d.label}\">${d.label}</td>`" + `;
+            let baselineVal = undefined;
+            idxs.forEach(function (idx) {
+                let val = d.values[idx];
+                if (baselineVal === undefined) {
+                    baselineVal = val;
+                    row += val === null ? "<td>-</td>" : ` + "`<td class=\"${vitalClass(d, val)}\">${formatValue(d, idx)}</td>`" + `;
+                    return;
+                }
+                if (val === null) {
+                    row += "<td>-</td>";
+                } else if (baselineVal === null || baselineVal == 0 || val == baselineVal) {
+                    row += ` + "`<td class=\"${vitalClass(d, val)}\">${formatValue(d, idx)}</td>`" + `;
+                } else {
+                    let diff = Math.round((val / baselineVal - 1) * 100);
+                    let color = isWorse(d, val, baselineVal) ? "red" : "green";
+                    row += ` + "`<td class=\"${vitalClass(d, val)}\">${formatValue(d, idx)} <span style=\"color: ${color}\">(${diff}%)</span></td>`" + `;
+                }
+            });
+
+            return row;
+        });
+}
+
+function sortByColValue(idx) {
+    let sortClass = document.querySelectorAll("#header-row > th")[idx + 1].classList;
+    let allRows = document.querySelectorAll("#trends-table-body > tr");
+    let allRowsArray = Array.from(allRows);
+    let emptyElems = allRowsArray.filter((elem) => elem.childNodes[idx + 1].innerText.trim() == "-");
+    allRowsArray = allRowsArray.filter((elem) => elem.childNodes[idx + 1].innerText.trim() != "-");
+    let orderDesc = sortClass.contains("desc");
+    document.querySelectorAll("#header-row > th:nth-child(n+2)").forEach((elem) => elem.classList.remove("desc"));
+    let pattern = /^([\d\.]+)/;
+    allRowsArray.sort(function (a, b) {
+        let aText = pattern.exec(a.childNodes[idx + 1].innerText)[1];
+        let bText = pattern.exec(b.childNodes[idx + 1].innerText)[1];
+        return orderDesc ? aText - bText : bText - aText;
+    });
+    if (!orderDesc) {
+        sortClass.add("desc");
+    }
+    let tableBody = document.querySelector("#trends-table-body");
+    tableBody.innerHTML = "";
+    allRowsArray.forEach((elem) => tableBody.appendChild(elem));
+    emptyElems.forEach((elem) => tableBody.appendChild(elem));
+}
+
+window.onload = rowsPopulate();
+
+var cursorX;
+var cursorY;
+document.onmousemove = function(e) {
+  cursorX = e.pageX;
+  cursorY = e.pageY;
+};
+`